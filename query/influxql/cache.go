@@ -0,0 +1,125 @@
+package influxql
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/influxdata/flux/ast"
+
+	"github.com/influxdata/influxql"
+)
+
+// TranspileCache stores the results of transpiling a query so identical
+// queries do not have to be transpiled again.
+//
+// A cache should not be shared between transpilers that use a different
+// Config since the transpiled result depends on the configuration. The
+// result also depends on the dbrp mappings, so a cached result is not
+// updated when a mapping changes.
+type TranspileCache interface {
+	// Get returns the result stored for the key.
+	Get(key string) (*TranspileResult, bool)
+
+	// Set stores the result for the key.
+	Set(key string, res *TranspileResult)
+}
+
+// LRUTranspileCache is a TranspileCache that evicts the least recently
+// used result once it holds more than its capacity.
+type LRUTranspileCache struct {
+	mu       sync.Mutex
+	cache    map[string]*list.Element
+	evictor  *list.List
+	capacity int
+}
+
+type transpileCacheElement struct {
+	key string
+	res *TranspileResult
+}
+
+// NewLRUTranspileCache returns an LRUTranspileCache with capacity n.
+func NewLRUTranspileCache(n int) *LRUTranspileCache {
+	return &LRUTranspileCache{
+		cache:    make(map[string]*list.Element),
+		evictor:  list.New(),
+		capacity: n,
+	}
+}
+
+// Get returns the result associated with the key if it exists.
+func (c *LRUTranspileCache) Get(key string) (*TranspileResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ele, ok := c.cache[key]
+	if !ok {
+		return nil, false
+	}
+	c.evictor.MoveToFront(ele) // This now becomes most recently used.
+	return ele.Value.(*transpileCacheElement).res, true
+}
+
+// Set stores the result for the key and evicts the least recently used
+// result if the cache is over capacity.
+func (c *LRUTranspileCache) Set(key string, res *TranspileResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if ele, ok := c.cache[key]; ok {
+		ele.Value.(*transpileCacheElement).res = res
+		c.evictor.MoveToFront(ele)
+		return
+	}
+
+	c.cache[key] = c.evictor.PushFront(&transpileCacheElement{
+		key: key,
+		res: res,
+	})
+	for c.evictor.Len() > c.capacity {
+		ele := c.evictor.Back()
+		c.evictor.Remove(ele)
+		delete(c.cache, ele.Value.(*transpileCacheElement).key)
+	}
+}
+
+// cacheKey returns the key used to cache the query. The query is formatted
+// from its parsed form so differences in whitespace and keyword case do not
+// result in different keys.
+func cacheKey(q *influxql.Query) string {
+	return q.String()
+}
+
+// copyResult returns a copy of the package and the warnings of the result
+// so the result that is cached cannot be modified by the caller.
+func copyResult(res *TranspileResult) *TranspileResult {
+	var warnings []Warning
+	if len(res.Warnings) > 0 {
+		warnings = make([]Warning, len(res.Warnings))
+		copy(warnings, res.Warnings)
+	}
+	return &TranspileResult{
+		Package:  res.Package.Copy().(*ast.Package),
+		Warnings: warnings,
+	}
+}
+
+// dependsOnNow returns true if the transpiled query depends on the time
+// the query is transpiled at.
+func dependsOnNow(q *influxql.Query) bool {
+	found := false
+	influxql.WalkFunc(q, func(node influxql.Node) {
+		switch node := node.(type) {
+		case *influxql.Call:
+			if node.Name == "now" {
+				found = true
+			}
+		case *influxql.SelectStatement:
+			// The end of the time range for a GROUP BY interval is the current time.
+			if interval, err := node.GroupByInterval(); err == nil && interval > 0 {
+				found = true
+			}
+		}
+	})
+	return found
+}
@@ -0,0 +1,170 @@
+package influxql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxdb/v2/query/influxql"
+	"github.com/influxdata/influxdb/v2/query/influxql/spectests"
+)
+
+func TestLRUTranspileCache(t *testing.T) {
+	cache := influxql.NewLRUTranspileCache(2)
+	a := &influxql.TranspileResult{Package: &ast.Package{Package: "a"}}
+	b := &influxql.TranspileResult{Package: &ast.Package{Package: "b"}}
+	c := &influxql.TranspileResult{Package: &ast.Package{Package: "c"}}
+	cache.Set("a", a)
+	cache.Set("b", b)
+
+	// Access a so b becomes the least recently used.
+	if got, ok := cache.Get("a"); !ok || got != a {
+		t.Fatalf("expected cache hit for a")
+	}
+	cache.Set("c", c)
+
+	if _, ok := cache.Get("b"); ok {
+		t.Errorf("expected b to be evicted")
+	}
+	if got, ok := cache.Get("a"); !ok || got != a {
+		t.Errorf("expected cache hit for a")
+	}
+	if got, ok := cache.Get("c"); !ok || got != c {
+		t.Errorf("expected cache hit for c")
+	}
+}
+
+func TestTranspiler_Cache(t *testing.T) {
+	cache := influxql.NewLRUTranspileCache(10)
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Cache:           cache,
+		},
+	)
+
+	first, err := transpiler.Transpile(context.Background(), `SELECT value FROM db0..cpu WHERE host = 'server01'`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want := ast.Format(first)
+
+	// Modify the returned package. This must not change the cached package.
+	first.Files[0].Body = nil
+
+	// The same query with different whitespace and keyword case must be a cache hit.
+	second, err := transpiler.Transpile(context.Background(), `select  value   from db0..cpu where host = 'server01'`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := ast.Format(second); got != want {
+		t.Errorf("unexpected output -want/+got:\n\t- %s\n\t+ %s", want, got)
+	}
+
+	// String literals are part of the key.
+	third, err := transpiler.Transpile(context.Background(), `SELECT value FROM db0..cpu WHERE host = 'SERVER01'`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got := ast.Format(third); got == want {
+		t.Errorf("expected a different result for a different string literal")
+	}
+}
+
+func TestTranspiler_CacheNow(t *testing.T) {
+	cache := influxql.NewLRUTranspileCache(10)
+	const q = `SELECT mean(value) FROM db0..cpu WHERE time >= now() - 10m GROUP BY time(1m)`
+
+	// Queries that depend on the current time are not cached.
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Cache:           cache,
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), q); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := cache.Get(q); ok {
+		t.Errorf("unexpected cache entry for a query using now()")
+	}
+
+	// They can be cached when the current time is fixed.
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			Cache:           cache,
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), q); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := cache.Get(q); !ok {
+		t.Errorf("expected cache entry when now is fixed")
+	}
+}
+
+func TestTranspiler_CacheWarnings(t *testing.T) {
+	var got []string
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Cache:           influxql.NewLRUTranspileCache(10),
+			OnWarning: func(w influxql.Warning) {
+				got = append(got, w.Message)
+			},
+		},
+	)
+
+	// The warnings are reported again when the query is returned from the cache.
+	const q = `SELECT value FROM db0..cpu tz('America/Los_Angeles')`
+	want := []string{`tz() function is not implemented and was ignored`}
+	for i := 0; i < 2; i++ {
+		got = nil
+		res, err := influxql.TranspileVerbose(context.Background(), transpiler, q)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !cmp.Equal(want, got) {
+			t.Errorf("%d: unexpected warnings -want/+got:\n%s", i, cmp.Diff(want, got))
+		}
+		if len(res.Warnings) != 1 || res.Warnings[0].Message != want[0] {
+			t.Errorf("%d: unexpected result warnings: %v", i, res.Warnings)
+		}
+	}
+}
+
+func TestTranspiler_CacheSchemaResolver(t *testing.T) {
+	cache := influxql.NewLRUTranspileCache(10)
+	resolver := &countingFieldKeys{
+		keys: map[string][]string{"cpu": {"value"}},
+	}
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Cache:           cache,
+			SchemaResolver:  resolver,
+		},
+	)
+
+	// The schema may change between queries so the result is not cached.
+	const q = `SELECT mean(*) FROM db0..cpu`
+	for i := 0; i < 2; i++ {
+		if _, err := transpiler.Transpile(context.Background(), q); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	if got, want := resolver.calls, 2; got != want {
+		t.Errorf("unexpected number of calls to the resolver: got=%d want=%d", got, want)
+	}
+	if _, ok := cache.Get(q); ok {
+		t.Errorf("unexpected cache entry for a query using a schema resolver")
+	}
+}
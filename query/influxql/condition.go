@@ -0,0 +1,202 @@
+package influxql
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxql"
+)
+
+// splitTimeCondition separates the time conditions from the rest of the condition.
+// It returns the remaining condition and the time ranges that should be read.
+// Time conditions that are combined with OR produce one time range for each
+// side of the OR.
+func splitTimeCondition(cond influxql.Expr, valuer influxql.Valuer) (influxql.Expr, []influxql.TimeRange, error) {
+	if !hasTimeDisjunction(cond) {
+		expr, tr, err := influxql.ConditionExpr(cond, valuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		return expr, []influxql.TimeRange{tr}, nil
+	}
+
+	switch expr := cond.(type) {
+	case *influxql.ParenExpr:
+		return splitTimeCondition(expr.Expr, valuer)
+	case *influxql.BinaryExpr:
+		lhs, lhsRanges, err := splitTimeCondition(expr.LHS, valuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		rhs, rhsRanges, err := splitTimeCondition(expr.RHS, valuer)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch expr.Op {
+		case influxql.OR:
+			// Each side of the OR reads its own time range so it cannot
+			// also contain a condition that is shared by both of them.
+			if lhs != nil || rhs != nil {
+				return nil, nil, errors.New("unimplemented: time conditions combined with other conditions using OR")
+			}
+			return nil, mergeTimeRanges(append(lhsRanges, rhsRanges...)), nil
+		case influxql.AND:
+			// Intersect every time range on the left with every time range on the right.
+			ranges := make([]influxql.TimeRange, 0, len(lhsRanges)*len(rhsRanges))
+			for _, l := range lhsRanges {
+				for _, r := range rhsRanges {
+					ranges = append(ranges, l.Intersect(r))
+				}
+			}
+
+			cond := lhs
+			if cond == nil {
+				cond = rhs
+			} else if rhs != nil {
+				cond = &influxql.BinaryExpr{
+					Op:  influxql.AND,
+					LHS: lhs,
+					RHS: rhs,
+				}
+			}
+			return cond, ranges, nil
+		}
+	}
+
+	expr, tr, err := influxql.ConditionExpr(cond, valuer)
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr, []influxql.TimeRange{tr}, nil
+}
+
+// timeCondition returns a condition that only matches the time ranges read
+// by the condition. It returns nil if every time is read.
+func timeCondition(cond influxql.Expr, valuer influxql.Valuer) (influxql.Expr, error) {
+	_, ranges, err := splitTimeCondition(cond, valuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var expr influxql.Expr
+	for _, tr := range ranges {
+		var bounds []influxql.Expr
+		if !tr.Min.IsZero() {
+			bounds = append(bounds, &influxql.BinaryExpr{
+				Op:  influxql.GTE,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: tr.Min},
+			})
+		}
+		if !tr.Max.IsZero() {
+			bounds = append(bounds, &influxql.BinaryExpr{
+				Op:  influxql.LTE,
+				LHS: &influxql.VarRef{Val: "time"},
+				RHS: &influxql.TimeLiteral{Val: tr.Max},
+			})
+		}
+
+		var rangeExpr influxql.Expr
+		switch len(bounds) {
+		case 0:
+			// This time range reads every time so the others do not matter.
+			return nil, nil
+		case 1:
+			rangeExpr = bounds[0]
+		default:
+			rangeExpr = &influxql.ParenExpr{
+				Expr: &influxql.BinaryExpr{
+					Op:  influxql.AND,
+					LHS: bounds[0],
+					RHS: bounds[1],
+				},
+			}
+		}
+
+		if expr == nil {
+			expr = rangeExpr
+		} else {
+			expr = &influxql.BinaryExpr{
+				Op:  influxql.OR,
+				LHS: expr,
+				RHS: rangeExpr,
+			}
+		}
+	}
+	if len(ranges) > 1 {
+		expr = &influxql.ParenExpr{Expr: expr}
+	}
+	return expr, nil
+}
+
+// hasTimeDisjunction returns true if the condition contains an OR where
+// either side references the time.
+func hasTimeDisjunction(cond influxql.Expr) bool {
+	found := false
+	influxql.WalkFunc(cond, func(node influxql.Node) {
+		if expr, ok := node.(*influxql.BinaryExpr); ok && expr.Op == influxql.OR {
+			if hasTimeRef(expr.LHS) || hasTimeRef(expr.RHS) {
+				found = true
+			}
+		}
+	})
+	return found
+}
+
+// hasTimeRef returns true if the expression references the time.
+func hasTimeRef(expr influxql.Expr) bool {
+	found := false
+	influxql.WalkFunc(expr, func(node influxql.Node) {
+		if ref, ok := node.(*influxql.VarRef); ok && strings.ToLower(ref.Val) == "time" {
+			found = true
+		}
+	})
+	return found
+}
+
+// isUnbounded returns true if any of the time ranges has no lower bound.
+func isUnbounded(ranges []influxql.TimeRange) bool {
+	for _, tr := range ranges {
+		if tr.MinTime().UnixNano() == influxql.MinTime {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeTimeRanges merges the time ranges that overlap or are adjacent so
+// each point is read once and removes the ranges that are empty. If every
+// range is empty, the first one is returned so the query reads nothing.
+func mergeTimeRanges(ranges []influxql.TimeRange) []influxql.TimeRange {
+	merged := make([]influxql.TimeRange, 0, len(ranges))
+	for _, tr := range ranges {
+		if !tr.MinTime().After(tr.MaxTime()) {
+			merged = append(merged, tr)
+		}
+	}
+	if len(merged) == 0 {
+		return ranges[:1]
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].MinTime().Before(merged[j].MinTime())
+	})
+	n := 0
+	for _, tr := range merged[1:] {
+		// The maximum is inclusive so a range that starts one
+		// nanosecond after the previous one ends is adjacent.
+		prev := &merged[n]
+		if tr.MinTime().After(prev.MaxTime().Add(time.Nanosecond)) {
+			n++
+			merged[n] = tr
+			continue
+		}
+		if tr.MaxTime().After(prev.MaxTime()) {
+			prev.Max = tr.Max
+		}
+	}
+	return merged[:n+1]
+}
@@ -1,19 +1,113 @@
 package influxql
 
 import (
+	"encoding/json"
 	"time"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/influxql"
 )
 
 // Config modifies the behavior of the Transpiler.
+//
+// The fields that are functions or interfaces are not encoded as JSON
+// and must be set after the Config is decoded.
 type Config struct {
 	// Bucket is the name of a bucket to use instead of the db/rp from the query.
 	// If bucket is empty then the dbrp mapping is used.
-	Bucket                 string
-	DefaultDatabase        string
-	DefaultRetentionPolicy string
-	Cluster                string
-	Now                    time.Time
+	Bucket                 string    `json:"bucket,omitempty"`
+	DefaultDatabase        string    `json:"defaultDatabase,omitempty"`
+	DefaultRetentionPolicy string    `json:"defaultRetentionPolicy,omitempty"`
+	Cluster                string    `json:"cluster,omitempty"`
+	Now                    time.Time `json:"now"`
+	// NowFn if set is called to determine the current time when Now is not set.
+	// It is called once for each call to Transpile so every use of now() in
+	// the query is the same time.
+	NowFn func() time.Time `json:"-"`
 	// FallbackToDBRP if true will use the naming convention of `db/rp`
 	// for a bucket name when an mapping is not found
-	FallbackToDBRP bool
+	FallbackToDBRP bool `json:"fallbackToDBRP,omitempty"`
+	// MaxOperationsN is the maximum number of function calls the transpiled
+	// query may contain. If zero, the number of function calls is unlimited.
+	MaxOperationsN int `json:"maxOperationsN,omitempty"`
+	// OptimizeDuplicateSources if true will read identical from, range,
+	// and filter expressions once and share the result between the cursors using them.
+	OptimizeDuplicateSources bool `json:"optimizeDuplicateSources,omitempty"`
+	// ShareSources if true will also share identical sources between the
+	// statements in the query instead of only within a single statement.
+	ShareSources bool `json:"shareSources,omitempty"`
+	// StrictMode if true will return an error when the query uses a feature
+	// that is not implemented instead of ignoring it.
+	StrictMode bool `json:"strictMode,omitempty"`
+	// MergeFilters if true will combine the conditions from the WHERE clause
+	// with the measurement and field filter so only one filter is used.
+	MergeFilters bool `json:"mergeFilters,omitempty"`
+	// Cache if set will store the transpiled queries so identical
+	// queries are only transpiled once. Queries are not cached when
+	// SchemaResolver, BucketFederationFn, ImplicitTimeRange, or
+	// IdentifierFn is set. TranspileStatement does not use the cache.
+	Cache TranspileCache `json:"-"`
+	// FunctionRegistry contains functions that are used instead of the built-in
+	// functions. The first argument to the function must be a field and the
+	// returned call is invoked with the values for that field piped into it.
+	FunctionRegistry map[string]FunctionFunc `json:"-"`
+	// SchemaResolver if set is used to expand wildcards and regular
+	// expressions in the fields to the field keys of the measurement.
+	SchemaResolver SchemaResolver `json:"-"`
+	// BucketFederationFn if set returns the names of the buckets that hold the
+	// data for a database and retention policy. The data is read from all of
+	// them instead of the bucket from the dbrp mapping. If it returns no
+	// buckets, the dbrp mapping is used. It is not used when Bucket is set.
+	// The retention policy is autogen when the query and DefaultRetentionPolicy
+	// do not have one.
+	BucketFederationFn func(db, rp string) []string `json:"-"`
+	// IdentifierFn if set returns the name of the nth variable that a table
+	// is assigned to instead of t0, t1, and so on. The names must be valid
+	// Flux identifiers. A name that is already in use is skipped and, if
+	// every name it returns is in use, t0, t1, and so on are used instead.
+	IdentifierFn func(n int) string `json:"-"`
+	// ImplicitTimeRange if set is called to find the time range of the data
+	// in a measurement when the query does not have a time condition. The
+	// range from start to stop is read instead of all time. The stop is
+	// inclusive so it can be the time of the last point. It is called
+	// once for each measurement in a call to Transpile. Like with
+	// BucketFederationFn, the retention policy is autogen when the query
+	// and DefaultRetentionPolicy do not have one.
+	ImplicitTimeRange func(db, rp, measurement string) (start, stop time.Time, err error) `json:"-"`
+	// Middleware if set wraps the function that transpiles the query text
+	// in Transpile. It can be used to record metrics, trace, or log each
+	// query without modifying the transpiler. TranspileReader, TranspileToFlux,
+	// and TranspileWithTimeout call Transpile so they are wrapped as well.
+	// TranspileVerbose and TranspileStatement are not wrapped.
+	Middleware func(next TranspileFunc) TranspileFunc `json:"-"`
+	// OnWarning if set is called for each part of the query that is
+	// deprecated or is ignored because it is not implemented.
+	OnWarning func(Warning) `json:"-"`
+}
+
+// MarshalJSON encodes the config as JSON. The omitempty option does
+// not omit a zero time.Time so Now is encoded as a pointer instead.
+func (c Config) MarshalJSON() ([]byte, error) {
+	type config Config
+	var now *time.Time
+	if !c.Now.IsZero() {
+		now = &c.Now
+	}
+	return json.Marshal(struct {
+		config
+		Now *time.Time `json:"now,omitempty"`
+	}{
+		config: config(c),
+		Now:    now,
+	})
 }
+
+// Warning describes a part of a query that was transpiled, but should
+// not be relied on.
+type Warning struct {
+	// Message describes the part of the query and why it was reported.
+	Message string
+}
+
+// FunctionFunc creates the call expression for an InfluxQL function call.
+type FunctionFunc func(call *influxql.Call) (*ast.CallExpression, error)
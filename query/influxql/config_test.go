@@ -0,0 +1,71 @@
+package influxql_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2/query/influxql"
+	"github.com/influxdata/influxdb/v2/query/influxql/spectests"
+)
+
+func TestConfig_JSON(t *testing.T) {
+	want := influxql.Config{
+		Bucket:                   "bucket0",
+		DefaultDatabase:          "db0",
+		DefaultRetentionPolicy:   "autogen",
+		Cluster:                  "cluster",
+		Now:                      spectests.Now(),
+		FallbackToDBRP:           true,
+		MaxOperationsN:           100,
+		OptimizeDuplicateSources: true,
+		ShareSources:             true,
+		StrictMode:               true,
+		MergeFilters:             true,
+	}
+
+	// The functions and interfaces are not encoded.
+	config := want
+	config.NowFn = time.Now
+	config.Cache = influxql.NewLRUTranspileCache(10)
+	config.OnWarning = func(influxql.Warning) {}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got influxql.Config
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected config -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestConfig_JSON_NowNotSet(t *testing.T) {
+	want := influxql.Config{
+		DefaultDatabase: "db0",
+	}
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, exp := string(data), `{"defaultDatabase":"db0"}`; got != exp {
+		t.Errorf("unexpected json: got=%s want=%s", got, exp)
+	}
+
+	var got influxql.Config
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !got.Now.IsZero() {
+		t.Errorf("expected now to not be set, got %s", got.Now)
+	}
+	if !cmp.Equal(want, got) {
+		t.Errorf("unexpected config -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
@@ -2,6 +2,8 @@ package influxql
 
 import (
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/execute"
@@ -33,39 +35,142 @@ type varRefCursor struct {
 // createVarRefCursor creates a new cursor from a variable reference using the sources
 // in the transpilerState.
 func createVarRefCursor(t *transpilerState, ref *influxql.VarRef) (cursor, error) {
-	if len(t.stmt.Sources) != 1 {
-		// TODO(jsternberg): Support multiple sources.
-		return nil, errors.New("unimplemented: only one source is allowed")
-	}
-
-	// Only support a direct measurement. Subqueries are not supported yet.
-	mm, ok := t.stmt.Sources[0].(*influxql.Measurement)
-	if !ok {
-		return nil, errors.New("unimplemented: source must be a measurement")
-	}
-
-	// Create the from spec and add it to the list of operations.
-	from, err := t.from(mm)
+	expr, err := createSource(t, ref)
 	if err != nil {
 		return nil, err
 	}
+	return &varRefCursor{
+		expr: expr,
+		ref:  ref,
+	}, nil
+}
 
+// createSource creates the expression that reads the values for the field
+// from the sources in the transpilerState. The field is either a variable
+// reference, a regex that matches the names of the fields, or a wildcard
+// that reads every field.
+func createSource(t *transpilerState, field influxql.Expr) (ast.Expression, error) {
 	valuer := influxql.NowValuer{Now: t.config.Now}
-	_, tr, err := influxql.ConditionExpr(t.stmt.Condition, &valuer)
+	_, ranges, err := splitTimeCondition(t.stmt.Condition, &valuer)
 	if err != nil {
 		return nil, err
 	}
 
+	// The time range of the data is used for a measurement when the query
+	// does not have a time condition. This is decided before the maximum
+	// is set for a windowing function below.
+	implicit := make([]bool, len(ranges))
+	for i, tr := range ranges {
+		implicit[i] = tr.Min.IsZero() && tr.Max.IsZero()
+	}
+
 	// If the maximum is not set and we have a windowing function, then
-	// the end time will be set to now.
-	if tr.Max.IsZero() {
-		if window, err := t.stmt.GroupByInterval(); err == nil && window > 0 {
-			tr.Max = t.config.Now
+	// the end time will be set to now. This is the same maximum as the
+	// one from time < now() so the range stops at now.
+	if window, err := t.stmt.GroupByInterval(); err == nil && window > 0 {
+		for i := range ranges {
+			if ranges[i].Max.IsZero() {
+				ranges[i].Max = t.config.Now.Add(-time.Nanosecond)
+			}
+		}
+	}
+
+	// Create a from, range, and filter for each of the sources and time ranges.
+	// When there is more than one, the resulting tables are merged with a union.
+	exprs := make([]ast.Expression, 0, len(t.stmt.Sources)*len(ranges))
+	for _, source := range t.stmt.Sources {
+		// The results of a subquery are read like a measurement.
+		if sq, ok := source.(*influxql.SubQuery); ok {
+			for _, tr := range ranges {
+				expr, err := t.subquerySource(sq, field, tr)
+				if err != nil {
+					return nil, err
+				}
+				exprs = append(exprs, expr)
+			}
+			continue
+		}
+
+		mm, ok := source.(*influxql.Measurement)
+		if !ok {
+			return nil, errors.New("unimplemented: source must be a measurement or a subquery")
+		}
+
+		for i, tr := range ranges {
+			if implicit[i] {
+				if tr, err = t.implicitTimeRange(mm, tr); err != nil {
+					return nil, err
+				}
+			}
+			expr, err := t.measurementSource(mm, field, tr)
+			if err != nil {
+				return nil, err
+			}
+			exprs = append(exprs, expr)
+		}
+	}
+
+	var expr ast.Expression
+	switch len(exprs) {
+	case 0:
+		return nil, errors.New("at least 1 source is required")
+	case 1:
+		expr = exprs[0]
+	default:
+		tables := make([]ast.Expression, 0, len(exprs))
+		for _, e := range exprs {
+			tables = append(tables, t.assignment(e))
+		}
+		expr = &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "union",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "tables",
+							},
+							Value: &ast.ArrayExpression{
+								Elements: tables,
+							},
+						},
+					},
+				},
+			},
+		}
+		// Each time range has its own start and stop, which are part of
+		// the group key. They are replaced with the bounds of all of the
+		// time ranges so a series is not split into a table per range.
+		if len(ranges) > 1 {
+			expr = mergeBounds(expr, ranges)
 		}
 	}
 
-	range_ := &ast.PipeExpression{
-		Argument: from,
+	// Read identical sources only once when multiple cursors use them.
+	if t.config.OptimizeDuplicateSources || t.config.ShareSources {
+		expr = t.sharedSource(expr)
+	}
+	return expr, nil
+}
+
+// rangeStop returns the stop of the range for the time range.
+func rangeStop(tr influxql.TimeRange) time.Time {
+	// The maximum of a time range is inclusive, but the stop of a range
+	// is exclusive so the stop is moved forward to include the maximum.
+	stop := tr.MaxTime()
+	if !tr.Max.IsZero() && stop.UnixNano() < influxql.MaxTime {
+		stop = stop.Add(time.Nanosecond)
+	}
+	return stop
+}
+
+// rangeTime reads the time range from the tables.
+func rangeTime(in ast.Expression, tr influxql.TimeRange) ast.Expression {
+	stop := rangeStop(tr)
+	return &ast.PipeExpression{
+		Argument: in,
 		Call: &ast.CallExpression{
 			Callee: &ast.Identifier{
 				Name: "range",
@@ -86,7 +191,7 @@ func createVarRefCursor(t *transpilerState, ref *influxql.VarRef) (cursor, error
 								Name: "stop",
 							},
 							Value: &ast.DateTimeLiteral{
-								Value: tr.MaxTime().UTC(),
+								Value: stop.UTC(),
 							},
 						},
 					},
@@ -94,60 +199,212 @@ func createVarRefCursor(t *transpilerState, ref *influxql.VarRef) (cursor, error
 			},
 		},
 	}
+}
 
-	expr := &ast.PipeExpression{
-		Argument: range_,
+// mergeBounds sets the start and stop of every row to the earliest start
+// and the latest stop of the time ranges.
+func mergeBounds(in ast.Expression, ranges []influxql.TimeRange) ast.Expression {
+	start, stop := ranges[0].MinTime(), rangeStop(ranges[0])
+	for _, tr := range ranges[1:] {
+		if min := tr.MinTime(); min.Before(start) {
+			start = min
+		}
+		if max := rangeStop(tr); max.After(stop) {
+			stop = max
+		}
+	}
+	return &ast.PipeExpression{
+		Argument: in,
 		Call: &ast.CallExpression{
 			Callee: &ast.Identifier{
-				Name: "filter",
+				Name: "map",
 			},
 			Arguments: []ast.Expression{
 				&ast.ObjectExpression{
-					Properties: []*ast.Property{
-						{
-							Key: &ast.Identifier{
-								Name: "fn",
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{
+							Name: "fn",
+						},
+						Value: &ast.FunctionExpression{
+							Params: []*ast.Property{{
+								Key: &ast.Identifier{Name: "r"},
+							}},
+							Body: &ast.ObjectExpression{
+								With: &ast.Identifier{Name: "r"},
+								Properties: []*ast.Property{
+									{
+										Key:   &ast.Identifier{Name: "_start"},
+										Value: &ast.DateTimeLiteral{Value: start.UTC()},
+									},
+									{
+										Key:   &ast.Identifier{Name: "_stop"},
+										Value: &ast.DateTimeLiteral{Value: stop.UTC()},
+									},
+								},
 							},
-							Value: &ast.FunctionExpression{
-								Params: []*ast.Property{{
-									Key: &ast.Identifier{
-										Name: "r",
+						},
+					}},
+				},
+			},
+		},
+	}
+}
+
+// subquerySource reads the values for the field from the results of the
+// subquery. The column with the name of the field becomes the value so the
+// results can be used like the values of a field from a measurement.
+func (t *transpilerState) subquerySource(sq *influxql.SubQuery, field influxql.Expr, tr influxql.TimeRange) (ast.Expression, error) {
+	ref, ok := field.(*influxql.VarRef)
+	if !ok {
+		return nil, errors.New("unimplemented: wildcard or regex fields with a subquery")
+	}
+	ident, ok := t.subqueries[sq]
+	if !ok {
+		return nil, fmt.Errorf("subquery was not transpiled: %s", sq)
+	}
+
+	return &ast.PipeExpression{
+		Argument: rangeTime(&ast.Identifier{Name: ident.Name}, tr),
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "map",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{
+							Name: "fn",
+						},
+						Value: &ast.FunctionExpression{
+							Params: []*ast.Property{{
+								Key: &ast.Identifier{Name: "r"},
+							}},
+							Body: &ast.ObjectExpression{
+								With: &ast.Identifier{Name: "r"},
+								Properties: []*ast.Property{
+									{
+										Key:   &ast.Identifier{Name: "_field"},
+										Value: &ast.StringLiteral{Value: ref.Val},
 									},
-								}},
-								Body: &ast.LogicalExpression{
-									Operator: ast.AndOperator,
-									Left: &ast.BinaryExpression{
-										Operator: ast.EqualOperator,
-										Left: &ast.MemberExpression{
+									{
+										Key: &ast.Identifier{Name: execute.DefaultValueColLabel},
+										Value: &ast.MemberExpression{
 											Object:   &ast.Identifier{Name: "r"},
-											Property: &ast.Identifier{Name: "_measurement"},
-										},
-										Right: &ast.StringLiteral{
-											Value: mm.Name,
+											Property: &ast.StringLiteral{Value: ref.Val},
 										},
 									},
-									Right: &ast.BinaryExpression{
-										Operator: ast.EqualOperator,
-										Left: &ast.MemberExpression{
-											Object:   &ast.Identifier{Name: "r"},
-											Property: &ast.Identifier{Name: "_field"},
-										},
-										Right: &ast.StringLiteral{
-											Value: ref.Val,
+								},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+// measurementSource creates the from, range, and filter expressions that read
+// the field from a single measurement.
+func (t *transpilerState) measurementSource(mm *influxql.Measurement, field influxql.Expr, tr influxql.TimeRange) (ast.Expression, error) {
+	var body ast.Expression = &ast.BinaryExpression{
+		Operator: ast.EqualOperator,
+		Left: &ast.MemberExpression{
+			Object:   &ast.Identifier{Name: "r"},
+			Property: &ast.Identifier{Name: "_measurement"},
+		},
+		Right: &ast.StringLiteral{
+			Value: mm.Name,
+		},
+	}
+	switch field := field.(type) {
+	case *influxql.VarRef:
+		body = &ast.LogicalExpression{
+			Operator: ast.AndOperator,
+			Left:     body,
+			Right: &ast.BinaryExpression{
+				Operator: ast.EqualOperator,
+				Left: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: "r"},
+					Property: &ast.Identifier{Name: "_field"},
+				},
+				Right: &ast.StringLiteral{
+					Value: field.Val,
+				},
+			},
+		}
+	case *influxql.RegexLiteral:
+		body = &ast.LogicalExpression{
+			Operator: ast.AndOperator,
+			Left:     body,
+			Right: &ast.BinaryExpression{
+				Operator: ast.RegexpMatchOperator,
+				Left: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: "r"},
+					Property: &ast.Identifier{Name: "_field"},
+				},
+				Right: &ast.RegexpLiteral{
+					Value: field.Val,
+				},
+			},
+		}
+	}
+
+	// Create the from spec and add it to the list of operations.
+	return t.from(mm, func(from ast.Expression) ast.Expression {
+		return &ast.PipeExpression{
+			Argument: rangeTime(from, tr),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "filter",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{
+									Name: "fn",
+								},
+								Value: &ast.FunctionExpression{
+									Params: []*ast.Property{{
+										Key: &ast.Identifier{
+											Name: "r",
 										},
-									},
+									}},
+									Body: body,
 								},
 							},
 						},
 					},
 				},
 			},
-		},
+		}
+	})
+}
+
+// implicitTimeRange returns the time range of the data in the measurement
+// from the ImplicitTimeRange function in the config. The time range is
+// returned unchanged if there is no function or the measurement is a regex.
+func (t *transpilerState) implicitTimeRange(mm *influxql.Measurement, tr influxql.TimeRange) (influxql.TimeRange, error) {
+	if t.config.ImplicitTimeRange == nil || mm.Regex != nil {
+		return tr, nil
 	}
-	return &varRefCursor{
-		expr: expr,
-		ref:  ref,
-	}, nil
+
+	db, rp, err := t.dbrp(mm)
+	if err != nil {
+		return influxql.TimeRange{}, err
+	}
+
+	key := db + "/" + rp + "/" + mm.Name
+	if tr, ok := t.timeRanges[key]; ok {
+		return tr, nil
+	}
+	start, stop, err := t.config.ImplicitTimeRange(db, rp, mm.Name)
+	if err != nil {
+		return influxql.TimeRange{}, err
+	}
+	tr = influxql.TimeRange{Min: start, Max: stop}
+	t.timeRanges[key] = tr
+	return tr, nil
 }
 
 func (c *varRefCursor) Expr() ast.Expression {
@@ -171,6 +428,128 @@ func (c *varRefCursor) Value(expr influxql.Expr) (string, bool) {
 	return "", false
 }
 
+// regexCursor contains a cursor for the values of the fields that match a regex.
+// It is used when the regex cannot be expanded to each of the fields.
+type regexCursor struct {
+	expr  ast.Expression
+	regex *influxql.RegexLiteral
+}
+
+// createRegexCursor creates a new cursor that reads the fields matching the
+// regex from the sources in the transpilerState.
+func createRegexCursor(t *transpilerState, regex *influxql.RegexLiteral) (cursor, error) {
+	expr, err := createSource(t, regex)
+	if err != nil {
+		return nil, err
+	}
+	return &regexCursor{
+		expr:  expr,
+		regex: regex,
+	}, nil
+}
+
+func (c *regexCursor) Expr() ast.Expression {
+	return c.expr
+}
+
+func (c *regexCursor) Keys() []influxql.Expr {
+	return []influxql.Expr{c.regex}
+}
+
+func (c *regexCursor) Value(expr influxql.Expr) (string, bool) {
+	if regex, ok := expr.(*influxql.RegexLiteral); ok && regex == c.regex {
+		return execute.DefaultValueColLabel, true
+	}
+	return "", false
+}
+
+// wildcardCursor contains a cursor for the points of every field. It is used
+// to count the points when count(*) cannot be expanded to each of the fields.
+type wildcardCursor struct {
+	expr     ast.Expression
+	wildcard *influxql.Wildcard
+}
+
+// createWildcardCursor creates a new cursor that reads every field from the
+// sources in the transpilerState. The fields can have different types so
+// the value of every point is replaced with 1, and the field is dropped so
+// the fields of a series are in the same table.
+func createWildcardCursor(t *transpilerState, wildcard *influxql.Wildcard) (cursor, error) {
+	expr, err := createSource(t, wildcard)
+	if err != nil {
+		return nil, err
+	}
+	expr = &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: expr,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "map",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key: &ast.Identifier{
+								Name: "fn",
+							},
+							Value: &ast.FunctionExpression{
+								Params: []*ast.Property{{
+									Key: &ast.Identifier{Name: "r"},
+								}},
+								Body: &ast.ObjectExpression{
+									With: &ast.Identifier{Name: "r"},
+									Properties: []*ast.Property{{
+										Key:   &ast.Identifier{Name: execute.DefaultValueColLabel},
+										Value: &ast.IntegerLiteral{Value: 1},
+									}},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "drop",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{
+							Name: "columns",
+						},
+						Value: &ast.ArrayExpression{
+							Elements: []ast.Expression{
+								&ast.StringLiteral{Value: "_field"},
+							},
+						},
+					}},
+				},
+			},
+		},
+	}
+	return &wildcardCursor{
+		expr:     expr,
+		wildcard: wildcard,
+	}, nil
+}
+
+func (c *wildcardCursor) Expr() ast.Expression {
+	return c.expr
+}
+
+func (c *wildcardCursor) Keys() []influxql.Expr {
+	return []influxql.Expr{c.wildcard}
+}
+
+func (c *wildcardCursor) Value(expr influxql.Expr) (string, bool) {
+	if wildcard, ok := expr.(*influxql.Wildcard); ok && wildcard == c.wildcard {
+		return execute.DefaultValueColLabel, true
+	}
+	return "", false
+}
+
 // pipeCursor wraps a cursor with a new expression while delegating all calls to the
 // wrapped cursor.
 type pipeCursor struct {
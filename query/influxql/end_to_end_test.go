@@ -84,21 +84,16 @@ var skipTests = map[string]string{
 	"series_agg_4":             "Transpiler: Implement cumulative_sum https://github.com/influxdata/influxdb/issues/10732",
 	"series_agg_5":             "add derivative support to the transpiler https://github.com/influxdata/influxdb/issues/10759",
 	"series_agg_6":             "Transpiler: Implement non_negative_derivative https://github.com/influxdata/influxdb/issues/10731",
-	"Subquery_0":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"Subquery_1":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"Subquery_2":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"Subquery_3":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"Subquery_4":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"Subquery_5":               "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"NestedSubquery_0":         "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"NestedSubquery_1":         "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"NestedSubquery_2":         "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"NestedSubquery_3":         "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"SimulatedHTTP_0":          "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"SimulatedHTTP_1":          "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"SimulatedHTTP_2":          "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"SimulatedHTTP_3":          "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
-	"SimulatedHTTP_4":          "Implement subqueries in the transpiler https://github.com/influxdata/influxdb/issues/10660",
+	"Subquery_0":               "Transpiler: the subquery selects a wildcard, which needs a SchemaResolver",
+	"Subquery_2":               "Transpiler: the subquery selects the tag t1 without ::tag so it is read as a field",
+	"Subquery_4":               "Transpiler: the subquery selects the tag t0 without ::tag so it is read as a field",
+	"NestedSubquery_0":         "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
+	"NestedSubquery_1":         "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
+	"SimulatedHTTP_0":          "Transpiler: each field is read from every subquery source, including the ones that do not select it",
+	"SimulatedHTTP_1":          "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
+	"SimulatedHTTP_2":          "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
+	"SimulatedHTTP_3":          "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
+	"SimulatedHTTP_4":          "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
 	"SelectorMath_0":           "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
 	"SelectorMath_1":           "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
 	"SelectorMath_2":           "Transpiler: unimplemented functions: top and bottom https://github.com/influxdata/influxdb/issues/10738",
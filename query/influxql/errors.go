@@ -1,7 +1,15 @@
 package influxql
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/influxdata/influxdb/v2"
+)
 
 var (
 	errDatabaseNameRequired = errors.New("database name required")
+	errNoDatabaseSpecified  = &influxdb.Error{
+		Code: influxdb.EInvalid,
+		Msg:  "unable to transpile: no database specified: use FROM db..measurement or set Config.DefaultDatabase",
+	}
 )
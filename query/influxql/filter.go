@@ -0,0 +1,96 @@
+package influxql
+
+import "github.com/influxdata/flux/ast"
+
+// filterCall creates a call to filter with the given expression as the
+// body of the predicate function.
+func filterCall(body ast.Expression) *ast.CallExpression {
+	return &ast.CallExpression{
+		Callee: &ast.Identifier{
+			Name: "filter",
+		},
+		Arguments: []ast.Expression{
+			&ast.ObjectExpression{
+				Properties: []*ast.Property{{
+					Key: &ast.Identifier{Name: "fn"},
+					Value: &ast.FunctionExpression{
+						Params: []*ast.Property{{
+							Key: &ast.Identifier{Name: "r"},
+						}},
+						Body: body,
+					},
+				}},
+			},
+		},
+	}
+}
+
+// filterBody returns the predicate of a call to filter.
+func filterBody(call *ast.CallExpression) (ast.Expression, bool) {
+	if ident, ok := call.Callee.(*ast.Identifier); !ok || ident.Name != "filter" {
+		return nil, false
+	}
+	if len(call.Arguments) != 1 {
+		return nil, false
+	}
+	obj, ok := call.Arguments[0].(*ast.ObjectExpression)
+	if !ok || len(obj.Properties) != 1 {
+		return nil, false
+	}
+	fn, ok := obj.Properties[0].Value.(*ast.FunctionExpression)
+	if !ok {
+		return nil, false
+	}
+	body, ok := fn.Body.(ast.Expression)
+	return body, ok
+}
+
+// AndFilters combines two calls to filter into a single call that only
+// keeps the rows that match both predicates. It returns nil if either
+// of the calls is not a filter.
+func AndFilters(a, b *ast.CallExpression) *ast.CallExpression {
+	return combineFilters(ast.AndOperator, a, b)
+}
+
+// OrFilters combines two calls to filter into a single call that keeps
+// the rows that match either predicate. It returns nil if either of the
+// calls is not a filter.
+func OrFilters(a, b *ast.CallExpression) *ast.CallExpression {
+	return combineFilters(ast.OrOperator, a, b)
+}
+
+func combineFilters(op ast.LogicalOperatorKind, a, b *ast.CallExpression) *ast.CallExpression {
+	lhs, ok := filterBody(a)
+	if !ok {
+		return nil
+	}
+	rhs, ok := filterBody(b)
+	if !ok {
+		return nil
+	}
+	// Create a new filter rather than modifying the existing ones
+	// since the expressions may be used by another cursor.
+	return filterCall(&ast.LogicalExpression{
+		Operator: op,
+		Left:     lhs,
+		Right:    rhs,
+	})
+}
+
+// mergeFilter combines the condition with the predicate of the filter
+// at the end of the expression. It returns nil if the expression does
+// not end with a filter.
+func mergeFilter(expr ast.Expression, cond ast.Expression) ast.Expression {
+	pipe, ok := expr.(*ast.PipeExpression)
+	if !ok {
+		return nil
+	}
+	call := AndFilters(pipe.Call, filterCall(cond))
+	if call == nil {
+		return nil
+	}
+	return &ast.PipeExpression{
+		Argument: pipe.Argument,
+		Call:     call,
+	}
+}
@@ -0,0 +1,74 @@
+package influxql_test
+
+import (
+	"testing"
+
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
+	"github.com/influxdata/influxdb/v2/query/influxql"
+)
+
+// parseCall parses the flux source of a single call expression.
+func parseCall(t *testing.T, src string) *ast.CallExpression {
+	t.Helper()
+	pkg := parser.ParseSource(src)
+	if ast.Check(pkg) > 0 {
+		t.Fatalf("unable to parse the flux script: %s", src)
+	}
+	stmt, ok := pkg.Files[0].Body[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("expected expression statement, got %T", pkg.Files[0].Body[0])
+	}
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected call expression, got %T", stmt.Expression)
+	}
+	return call
+}
+
+func TestAndFilters(t *testing.T) {
+	a := parseCall(t, `filter(fn: (r) => r._measurement == "cpu")`)
+	b := parseCall(t, `filter(fn: (r) => r.host == "server01" or r.host == "server02")`)
+	got := influxql.AndFilters(a, b)
+	if got == nil {
+		t.Fatal("expected filter")
+	}
+	want := parseCall(t, `filter(fn: (r) => r._measurement == "cpu" and (r.host == "server01" or r.host == "server02"))`)
+	if got, want := ast.Format(got), ast.Format(want); got != want {
+		t.Errorf("unexpected filter:\n\tgot=%s\n\twant=%s", got, want)
+	}
+
+	// The filters that were combined are not modified.
+	if got, want := ast.Format(a), ast.Format(parseCall(t, `filter(fn: (r) => r._measurement == "cpu")`)); got != want {
+		t.Errorf("unexpected change to the filter:\n\tgot=%s\n\twant=%s", got, want)
+	}
+}
+
+func TestOrFilters(t *testing.T) {
+	a := parseCall(t, `filter(fn: (r) => r._measurement == "cpu" and r._field == "value")`)
+	b := parseCall(t, `filter(fn: (r) => r._measurement == "mem")`)
+	got := influxql.OrFilters(a, b)
+	if got == nil {
+		t.Fatal("expected filter")
+	}
+	want := parseCall(t, `filter(fn: (r) => r._measurement == "cpu" and r._field == "value" or r._measurement == "mem")`)
+	if got, want := ast.Format(got), ast.Format(want); got != want {
+		t.Errorf("unexpected filter:\n\tgot=%s\n\twant=%s", got, want)
+	}
+}
+
+func TestAndFilters_NotFilter(t *testing.T) {
+	filter := parseCall(t, `filter(fn: (r) => r._measurement == "cpu")`)
+	for _, src := range []string{
+		`map(fn: (r) => ({r with _value: 1}))`,
+		`filter(fn: (r) => r._value > 0, onEmpty: "keep")`,
+	} {
+		call := parseCall(t, src)
+		if got := influxql.AndFilters(filter, call); got != nil {
+			t.Errorf("expected nil for %s, got %s", src, ast.Format(got))
+		}
+		if got := influxql.OrFilters(call, filter); got != nil {
+			t.Errorf("expected nil for %s, got %s", src, ast.Format(got))
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/influxdata/flux/ast"
 	"github.com/influxdata/flux/execute"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxql"
 )
 
@@ -14,7 +15,7 @@ func isTransformation(expr influxql.Expr) bool {
 	if call, ok := expr.(*influxql.Call); ok {
 		switch call.Name {
 		// TODO(ethan): more to be added here.
-		case "difference", "derivative", "cumulative_sum", "elapsed":
+		case "difference", "derivative", "cumulative_sum", "elapsed", "moving_average":
 			return true
 		}
 	}
@@ -52,7 +53,11 @@ func parseFunction(expr *influxql.Call) (*function, error) {
 		case *influxql.Distinct:
 			return nil, errors.New("unimplemented: count(distinct)")
 		case *influxql.Wildcard:
-			return nil, errors.New("unimplemented: wildcard function")
+			// Without a SchemaResolver, count(*) counts the points
+			// rather than the values of each field.
+			return &function{
+				call: expr,
+			}, nil
 		case *influxql.RegexLiteral:
 			return nil, errors.New("unimplemented: wildcard regex function")
 		default:
@@ -76,6 +81,22 @@ func parseFunction(expr *influxql.Call) (*function, error) {
 		default:
 			return nil, fmt.Errorf("expected field argument in %s()", expr.Name)
 		}
+	case "distinct":
+		if len(expr.Args) == 0 {
+			return nil, errors.New("distinct function requires at least one argument")
+		} else if len(expr.Args) != 1 {
+			return nil, errors.New("distinct function can only have one argument")
+		}
+
+		switch ref := expr.Args[0].(type) {
+		case *influxql.VarRef:
+			return &function{
+				Ref:  ref,
+				call: expr,
+			}, nil
+		default:
+			return nil, fmt.Errorf("expected field argument in %s()", expr.Name)
+		}
 	case "percentile":
 		if exp, got := 2, len(expr.Args); exp != got {
 			return nil, fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
@@ -105,12 +126,103 @@ func parseFunction(expr *influxql.Call) (*function, error) {
 			Ref:  functionRef,
 			call: expr,
 		}, nil
+	case "sample":
+		if exp, got := 2, len(expr.Args); exp != got {
+			return nil, fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+		}
+
+		var functionRef *influxql.VarRef
+
+		switch ref := expr.Args[0].(type) {
+		case *influxql.VarRef:
+			functionRef = ref
+		case *influxql.Wildcard:
+			return nil, errors.New("unimplemented: wildcard function")
+		case *influxql.RegexLiteral:
+			return nil, errors.New("unimplemented: wildcard regex function")
+		default:
+			return nil, fmt.Errorf("expected field argument in %s()", expr.Name)
+		}
+
+		switch arg := expr.Args[1].(type) {
+		case *influxql.IntegerLiteral:
+			if arg.Val <= 1 {
+				return nil, fmt.Errorf("sample window must be greater than 1, got %d", arg.Val)
+			}
+		default:
+			return nil, fmt.Errorf("expected integer argument in %s()", expr.Name)
+		}
+
+		return &function{
+			Ref:  functionRef,
+			call: expr,
+		}, nil
+	case "moving_average":
+		if exp, got := 2, len(expr.Args); exp != got {
+			return nil, fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, exp, got)
+		}
+
+		switch arg := expr.Args[1].(type) {
+		case *influxql.IntegerLiteral:
+			if arg.Val <= 1 {
+				return nil, fmt.Errorf("%s window must be greater than 1, got %d", expr.Name, arg.Val)
+			}
+		default:
+			return nil, fmt.Errorf("second argument for %s must be an integer, got %T", expr.Name, expr.Args[1])
+		}
+
+		// The moving average is either of the raw values of a field
+		// or of an aggregate of the field in each window.
+		switch ref := expr.Args[0].(type) {
+		case *influxql.VarRef:
+			return &function{
+				Ref:  ref,
+				call: expr,
+			}, nil
+		case *influxql.Call:
+			fn, err := parseFunction(ref)
+			if err != nil {
+				return nil, err
+			}
+			return &function{
+				Ref:  fn.Ref,
+				call: expr,
+			}, nil
+		case *influxql.Wildcard:
+			return nil, errors.New("unimplemented: wildcard function")
+		case *influxql.RegexLiteral:
+			return nil, errors.New("unimplemented: wildcard regex function")
+		default:
+			return nil, fmt.Errorf("expected field argument in %s()", expr.Name)
+		}
 	default:
-		return nil, fmt.Errorf("unimplemented function: %q", expr.Name)
+		if _, ok := influxqlFunctions[expr.Name]; !ok {
+			return nil, fmt.Errorf("undefined function %s()", expr.Name)
+		}
+		return nil, fmt.Errorf("unimplemented: function %s", expr.Name)
 	}
 
 }
 
+// influxqlFunctions contains the names of the functions in InfluxQL. A call
+// to any other function is undefined rather than unimplemented.
+var influxqlFunctions = map[string]struct{}{
+	"abs": {}, "acos": {}, "asin": {}, "atan": {}, "atan2": {}, "bottom": {},
+	"ceil": {}, "chande_momentum_oscillator": {}, "cos": {}, "count": {},
+	"cumulative_sum": {}, "derivative": {}, "difference": {}, "distinct": {},
+	"double_exponential_moving_average": {}, "elapsed": {}, "exp": {},
+	"exponential_moving_average": {}, "first": {}, "floor": {},
+	"holt_winters": {}, "holt_winters_with_fit": {}, "integral": {},
+	"kaufmans_adaptive_moving_average": {}, "kaufmans_efficiency_ratio": {},
+	"last": {}, "ln": {}, "log": {}, "log10": {}, "log2": {}, "max": {},
+	"mean": {}, "median": {}, "min": {}, "mode": {}, "moving_average": {},
+	"non_negative_derivative": {}, "non_negative_difference": {},
+	"percentile": {}, "pow": {}, "relative_strength_index": {}, "round": {},
+	"sample": {}, "sin": {}, "spread": {}, "sqrt": {}, "stddev": {}, "sum": {},
+	"tan": {}, "top": {}, "triple_exponential_derivative": {},
+	"triple_exponential_moving_average": {},
+}
+
 // createFunctionCursor creates a new cursor that calls a function on one of the columns
 // and returns the result.
 func createFunctionCursor(t *transpilerState, call *influxql.Call, in cursor, normalize bool) (cursor, error) {
@@ -118,14 +230,67 @@ func createFunctionCursor(t *transpilerState, call *influxql.Call, in cursor, no
 		call:   call,
 		parent: in,
 	}
-	switch call.Name {
-	case "count", "min", "max", "sum", "first", "last", "mean", "difference", "stddev", "spread":
+	// Functions in the registry are used instead of the built-in functions.
+	if fn, ok := t.config.FunctionRegistry[call.Name]; ok {
 		value, ok := in.Value(call.Args[0])
 		if !ok {
 			return nil, fmt.Errorf("undefined variable: %s", call.Args[0])
 		}
+		expr, err := fn(call)
+		if err != nil {
+			return nil, &influxdb.Error{
+				Code: influxdb.EInvalid,
+				Msg:  fmt.Sprintf("unable to transpile function %s", call.Name),
+				Err:  err,
+			}
+		}
 		cur.expr = &ast.PipeExpression{
 			Argument: in.Expr(),
+			Call:     expr,
+		}
+		cur.value = value
+		cur.exclude = map[influxql.Expr]struct{}{call.Args[0]: {}}
+		if normalize {
+			if err := normalizeTime(t, call, cur); err != nil {
+				return nil, err
+			}
+		}
+		return cur, nil
+	}
+
+	switch call.Name {
+	case "count", "min", "max", "sum", "first", "last", "mean", "difference", "stddev", "spread", "distinct":
+		value, ok := in.Value(call.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("undefined variable: %s", call.Args[0])
+		}
+		expr := in.Expr()
+		if _, ok := call.Args[0].(*influxql.Wildcard); ok {
+			// Every field of a point has the same time so each
+			// time is only counted once.
+			expr = &ast.PipeExpression{
+				Argument: expr,
+				Call: &ast.CallExpression{
+					Callee: &ast.Identifier{
+						Name: "unique",
+					},
+					Arguments: []ast.Expression{
+						&ast.ObjectExpression{
+							Properties: []*ast.Property{{
+								Key: &ast.Identifier{
+									Name: "column",
+								},
+								Value: &ast.StringLiteral{
+									Value: execute.DefaultTimeColLabel,
+								},
+							}},
+						},
+					},
+				},
+			}
+		}
+		cur.expr = &ast.PipeExpression{
+			Argument: expr,
 			Call: &ast.CallExpression{
 				Callee: &ast.Identifier{
 					Name: call.Name,
@@ -275,109 +440,279 @@ func createFunctionCursor(t *transpilerState, call *influxql.Call, in cursor, no
 		}
 		cur.value = fieldName
 		cur.exclude = map[influxql.Expr]struct{}{call.Args[0]: {}}
+	case "moving_average":
+		fieldName, ok := in.Value(call.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("undefined variable: %s", call.Args[0])
+		} else if fieldName != execute.DefaultValueColLabel {
+			// The movingAverage() function in flux only averages the value column.
+			return nil, fmt.Errorf("unimplemented: %s of a joined field", call.Name)
+		}
+
+		// The number of points was validated when the function was parsed.
+		n := call.Args[1].(*influxql.IntegerLiteral)
+		cur.expr = &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "movingAverage",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key: &ast.Identifier{
+								Name: "n",
+							},
+							Value: &ast.IntegerLiteral{
+								Value: n.Val,
+							},
+						}},
+					},
+				},
+			},
+		}
+		cur.value = fieldName
+		cur.exclude = map[influxql.Expr]struct{}{call.Args[0]: {}}
+	case "sample":
+		fieldName, ok := in.Value(call.Args[0])
+		if !ok {
+			return nil, fmt.Errorf("undefined variable: %s", call.Args[0])
+		}
+
+		// The sample() function in flux keeps every nth point, but influxql
+		// chooses n random points. Each point is ordered by a hash of its
+		// time so the first n points are a random sample, and the same
+		// points are chosen every time the query is run.
+		n := call.Args[1].(*influxql.IntegerLiteral)
+		cur.expr = sample(in.Expr(), n.Val)
+		cur.value = fieldName
+		cur.exclude = map[influxql.Expr]struct{}{call.Args[0]: {}}
+
+		// The sampled points keep their own time rather than the
+		// time of the window.
+		normalize = false
 	default:
-		return nil, fmt.Errorf("unimplemented function: %q", call.Name)
+		return nil, fmt.Errorf("unimplemented: function %s", call.Name)
 	}
 
 	// If we have been told to normalize the time, we do it here.
 	if normalize {
-		if influxql.IsSelector(call) {
-			cur.expr = &ast.PipeExpression{
-				Argument: cur.expr,
-				Call: &ast.CallExpression{
-					Callee: &ast.Identifier{
-						Name: "drop",
+		if err := normalizeTime(t, call, cur); err != nil {
+			return nil, err
+		}
+	}
+	return cur, nil
+}
+
+// sample chooses n points from each table. The points are ordered by
+// a multiplicative hash of their time, the first n are kept, and they
+// are sorted by time again.
+func sample(in ast.Expression, n int64) ast.Expression {
+	sortBy := func(in ast.Expression, column string) ast.Expression {
+		return &ast.PipeExpression{
+			Argument: in,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "sort"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key: &ast.Identifier{Name: "columns"},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{&ast.StringLiteral{Value: column}},
+							},
+						}},
 					},
+				},
+			},
+		}
+	}
+
+	// The time is reduced modulo 2^31-1 before it is multiplied so
+	// the hash cannot overflow.
+	const modulus, multiplier = 2147483647, 48271
+	hash := &ast.BinaryExpression{
+		Operator: ast.ModuloOperator,
+		Left: &ast.BinaryExpression{
+			Operator: ast.MultiplicationOperator,
+			Left: &ast.BinaryExpression{
+				Operator: ast.ModuloOperator,
+				Left: &ast.CallExpression{
+					Callee: &ast.Identifier{Name: "int"},
 					Arguments: []ast.Expression{
 						&ast.ObjectExpression{
 							Properties: []*ast.Property{{
-								Key: &ast.Identifier{
-									Name: "columns",
-								},
-								Value: &ast.ArrayExpression{
-									Elements: []ast.Expression{
-										&ast.StringLiteral{Value: execute.DefaultTimeColLabel},
-									},
+								Key: &ast.Identifier{Name: "v"},
+								Value: &ast.MemberExpression{
+									Object:   &ast.Identifier{Name: "r"},
+									Property: &ast.Identifier{Name: execute.DefaultTimeColLabel},
 								},
 							}},
 						},
 					},
 				},
-			}
-		}
-		// err checked in caller
-		interval, _ := t.stmt.GroupByInterval()
-		var timeValue ast.Expression
-		if interval > 0 {
-			timeValue = &ast.MemberExpression{
-				Object: &ast.Identifier{
-					Name: "r",
-				},
-				Property: &ast.Identifier{
-					Name: execute.DefaultStartColLabel,
+				Right: &ast.IntegerLiteral{Value: modulus},
+			},
+			Right: &ast.IntegerLiteral{Value: multiplier},
+		},
+		Right: &ast.IntegerLiteral{Value: modulus},
+	}
+
+	expr := &ast.PipeExpression{
+		Argument: in,
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "map"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{Name: "fn"},
+						Value: &ast.FunctionExpression{
+							Params: []*ast.Property{{
+								Key: &ast.Identifier{Name: "r"},
+							}},
+							Body: &ast.ObjectExpression{
+								With: &ast.Identifier{Name: "r"},
+								Properties: []*ast.Property{{
+									Key:   &ast.Identifier{Name: "_sample"},
+									Value: hash,
+								}},
+							},
+						},
+					}},
 				},
-			}
-		} else if isTransformation(call) || influxql.IsSelector(call) {
-			timeValue = &ast.MemberExpression{
-				Object: &ast.Identifier{
-					Name: "r",
+			},
+		},
+	}
+	expr = &ast.PipeExpression{
+		Argument: sortBy(expr, "_sample"),
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "limit"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key:   &ast.Identifier{Name: "n"},
+						Value: &ast.IntegerLiteral{Value: n},
+					}},
 				},
-				Property: &ast.Identifier{
-					Name: execute.DefaultTimeColLabel,
+			},
+		},
+	}
+	return &ast.PipeExpression{
+		Argument: sortBy(expr, execute.DefaultTimeColLabel),
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "drop"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{Name: "columns"},
+						Value: &ast.ArrayExpression{
+							Elements: []ast.Expression{&ast.StringLiteral{Value: "_sample"}},
+						},
+					}},
 				},
-			}
-		} else {
-			valuer := influxql.NowValuer{Now: t.config.Now}
-			_, tr, err := influxql.ConditionExpr(t.stmt.Condition, &valuer)
-			if err != nil {
-				return nil, err
-			}
-			if tr.MinTime().UnixNano() == influxql.MinTime {
-				timeValue = &ast.DateTimeLiteral{Value: time.Unix(0, 0).UTC()}
-			} else {
-				timeValue = &ast.MemberExpression{
-					Object: &ast.Identifier{
-						Name: "r",
-					},
-					Property: &ast.Identifier{
-						Name: execute.DefaultStartColLabel,
-					},
-				}
-			}
-		}
+			},
+		},
+	}
+}
+
+// normalizeTime sets the time of each value returned by the function to
+// the time that influxql reports for the function.
+func normalizeTime(t *transpilerState, call *influxql.Call, cur *functionCursor) error {
+	if influxql.IsSelector(call) {
 		cur.expr = &ast.PipeExpression{
 			Argument: cur.expr,
 			Call: &ast.CallExpression{
 				Callee: &ast.Identifier{
-					Name: "map",
+					Name: "drop",
 				},
 				Arguments: []ast.Expression{
 					&ast.ObjectExpression{
-						Properties: []*ast.Property{
-							{
-								Key: &ast.Identifier{
-									Name: "fn",
+						Properties: []*ast.Property{{
+							Key: &ast.Identifier{
+								Name: "columns",
+							},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{
+									&ast.StringLiteral{Value: execute.DefaultTimeColLabel},
 								},
-								Value: &ast.FunctionExpression{
-									Params: []*ast.Property{{
-										Key: &ast.Identifier{Name: "r"},
+							},
+						}},
+					},
+				},
+			},
+		}
+	}
+	// err checked in caller
+	interval, _ := t.stmt.GroupByInterval()
+	var timeValue ast.Expression
+	if interval > 0 {
+		timeValue = &ast.MemberExpression{
+			Object: &ast.Identifier{
+				Name: "r",
+			},
+			Property: &ast.Identifier{
+				Name: execute.DefaultStartColLabel,
+			},
+		}
+	} else if isTransformation(call) {
+		timeValue = &ast.MemberExpression{
+			Object: &ast.Identifier{
+				Name: "r",
+			},
+			Property: &ast.Identifier{
+				Name: execute.DefaultTimeColLabel,
+			},
+		}
+	} else {
+		valuer := influxql.NowValuer{Now: t.config.Now}
+		_, ranges, err := splitTimeCondition(t.stmt.Condition, &valuer)
+		if err != nil {
+			return err
+		}
+		if isUnbounded(ranges) {
+			timeValue = &ast.DateTimeLiteral{Value: time.Unix(0, 0).UTC()}
+		} else {
+			timeValue = &ast.MemberExpression{
+				Object: &ast.Identifier{
+					Name: "r",
+				},
+				Property: &ast.Identifier{
+					Name: execute.DefaultStartColLabel,
+				},
+			}
+		}
+	}
+	cur.expr = &ast.PipeExpression{
+		Argument: cur.expr,
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "map",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "fn",
+							},
+							Value: &ast.FunctionExpression{
+								Params: []*ast.Property{{
+									Key: &ast.Identifier{Name: "r"},
+								}},
+								Body: &ast.ObjectExpression{
+									With: &ast.Identifier{Name: "r"},
+									Properties: []*ast.Property{{
+										Key:   &ast.Identifier{Name: execute.DefaultTimeColLabel},
+										Value: timeValue,
 									}},
-									Body: &ast.ObjectExpression{
-										With: &ast.Identifier{Name: "r"},
-										Properties: []*ast.Property{{
-											Key:   &ast.Identifier{Name: execute.DefaultTimeColLabel},
-											Value: timeValue,
-										}},
-									},
 								},
 							},
 						},
 					},
 				},
 			},
-		}
+		},
 	}
-	return cur, nil
+	return nil
 }
 
 type functionCursor struct {
@@ -14,13 +14,15 @@ import (
 type groupInfo struct {
 	call              *influxql.Call
 	refs              []*influxql.VarRef
+	regex             *influxql.RegexLiteral
 	needNormalization bool
 }
 
 type groupVisitor struct {
-	calls []*function
-	refs  []*influxql.VarRef
-	err   error
+	calls    []*function
+	refs     []*influxql.VarRef
+	registry map[string]FunctionFunc
+	err      error
 }
 
 func (v *groupVisitor) Visit(n influxql.Node) influxql.Visitor {
@@ -32,6 +34,22 @@ func (v *groupVisitor) Visit(n influxql.Node) influxql.Visitor {
 	switch expr := n.(type) {
 	case *influxql.Call:
 		// TODO(jsternberg): Identify math functions so we visit their arguments instead of recording them.
+		if _, ok := v.registry[expr.Name]; ok {
+			if len(expr.Args) == 0 {
+				v.err = fmt.Errorf("expected field argument in %s()", expr.Name)
+				return nil
+			}
+			ref, ok := expr.Args[0].(*influxql.VarRef)
+			if !ok {
+				v.err = fmt.Errorf("expected field argument in %s()", expr.Name)
+				return nil
+			}
+			v.calls = append(v.calls, &function{
+				Ref:  ref,
+				call: expr,
+			})
+			return nil
+		}
 		fn, err := parseFunction(expr)
 		if err != nil {
 			v.err = err
@@ -58,9 +76,115 @@ func (v *groupVisitor) Visit(n influxql.Node) influxql.Visitor {
 	return v
 }
 
+// checkMixedFields returns an error if the fields mix aggregates with raw
+// fields. It only looks at the shape of the fields so the error is reported
+// even when other parts of the query cannot be transpiled. Calls that are
+// invalid are left for identifyGroups to report.
+func checkMixedFields(fields influxql.Fields) error {
+	var aggregate, raw, invalid bool
+	var visit func(expr influxql.Expr)
+	visit = func(expr influxql.Expr) {
+		switch expr := expr.(type) {
+		case *influxql.Call:
+			if isMathFunction(expr) {
+				for _, arg := range expr.Args {
+					visit(arg)
+				}
+				return
+			}
+			// A call with a wildcard is expanded to multiple calls so it cannot
+			// be used with raw fields even if it is a selector.
+			for _, arg := range expr.Args {
+				switch arg.(type) {
+				case *influxql.Wildcard, *influxql.RegexLiteral:
+					aggregate = true
+					return
+				}
+			}
+			if _, err := parseFunction(expr); err != nil {
+				invalid = true
+			} else if !influxql.IsSelector(expr) {
+				aggregate = true
+			}
+		case *influxql.BinaryExpr:
+			visit(expr.LHS)
+			visit(expr.RHS)
+		case *influxql.ParenExpr:
+			visit(expr.Expr)
+		case *influxql.VarRef:
+			if strings.ToLower(expr.Val) != "time" {
+				raw = true
+			}
+		case *influxql.Wildcard, *influxql.RegexLiteral:
+			raw = true
+		}
+	}
+	for _, f := range fields {
+		visit(f.Expr)
+	}
+
+	if aggregate && raw && !invalid {
+		return errors.New("mixing aggregate and non-aggregate queries is not supported")
+	}
+	return nil
+}
+
+// checkDistinct returns an error if distinct() is used with any other field.
+// A distinct field can be written as a call or with the distinct keyword and
+// it may be counted with count(distinct()).
+func checkDistinct(fields influxql.Fields) error {
+	if len(fields) < 2 {
+		return nil
+	}
+	for _, f := range fields {
+		if isDistinct(f.Expr) {
+			return errors.New("aggregate function distinct() cannot be combined with other functions or fields")
+		}
+	}
+	return nil
+}
+
+// checkLiteralFields returns an error if a field is a literal or only
+// contains arithmetic on literals. A field like this has no name.
+func checkLiteralFields(fields influxql.Fields) error {
+	for _, f := range fields {
+		switch influxql.Reduce(f.Expr, nil).(type) {
+		case *influxql.RegexLiteral:
+			// A regex selects the fields that match it.
+		case influxql.Literal:
+			return errors.New("field must contain at least one variable")
+		}
+	}
+	return nil
+}
+
+// isDistinct returns true if the expression is distinct() or count(distinct()).
+func isDistinct(expr influxql.Expr) bool {
+	switch expr := expr.(type) {
+	case *influxql.Call:
+		switch expr.Name {
+		case "distinct":
+			return true
+		case "count":
+			return len(expr.Args) == 1 && isDistinct(expr.Args[0])
+		}
+	case *influxql.Distinct:
+		return true
+	}
+	return false
+}
+
 // identifyGroups will identify the groups for creating data access cursors.
-func identifyGroups(stmt *influxql.SelectStatement) ([]*groupInfo, error) {
-	v := &groupVisitor{}
+func identifyGroups(stmt *influxql.SelectStatement, registry map[string]FunctionFunc) ([]*groupInfo, error) {
+	// A regex that was not expanded by a SchemaResolver reads every field
+	// that matches it. It cannot be combined with other fields.
+	if len(stmt.Fields) == 1 {
+		if regex, ok := stmt.Fields[0].Expr.(*influxql.RegexLiteral); ok {
+			return []*groupInfo{{regex: regex}}, nil
+		}
+	}
+
+	v := &groupVisitor{registry: registry}
 	influxql.Walk(v, stmt.Fields)
 	if v.err != nil {
 		return nil, v.err
@@ -96,6 +220,11 @@ func identifyGroups(stmt *influxql.SelectStatement) ([]*groupInfo, error) {
 	// its own group.
 	groups := make([]*groupInfo, 0, len(v.calls))
 	for _, fn := range v.calls {
+		// The points chosen by sample() keep their own time so they cannot
+		// be joined with the other functions that report the window time.
+		if (fn.call.Name == "sample") != (v.calls[0].call.Name == "sample") {
+			return nil, errors.New("unimplemented: sample() with other functions")
+		}
 		groups = append(groups, &groupInfo{call: fn.call})
 	}
 
@@ -103,6 +232,12 @@ func identifyGroups(stmt *influxql.SelectStatement) ([]*groupInfo, error) {
 	// then mark it does not need normalization.
 	if len(groups) == 1 {
 		groups[0].needNormalization = !isTransformation(groups[0].call) && !influxql.IsSelector(groups[0].call)
+	} else {
+		// Multiple groups are joined on the time so every aggregate and selector
+		// needs to report the same time.
+		for _, gr := range groups {
+			gr.needNormalization = !isTransformation(gr.call)
+		}
 	}
 	return groups, nil
 }
@@ -111,20 +246,48 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 	// Create all of the cursors for every variable reference.
 	// TODO(jsternberg): Determine which of these cursors are from fields and which are tags.
 	var cursors []cursor
-	if gr.call != nil {
-		ref, ok := gr.call.Args[0].(*influxql.VarRef)
-		if !ok {
+
+	// A transformation of an aggregate, such as moving_average(mean(value), 3),
+	// reads the field for the aggregate and transforms the result of it.
+	call, transform := gr.aggregate(), (*influxql.Call)(nil)
+	if call != gr.call {
+		transform = gr.call
+	}
+
+	if call != nil {
+		var (
+			cur cursor
+			err error
+		)
+		switch arg := call.Args[0].(type) {
+		case *influxql.VarRef:
+			cur, err = createVarRefCursor(t, arg)
+		case *influxql.Wildcard:
+			cur, err = createWildcardCursor(t, arg)
+		default:
 			// TODO(jsternberg): This should be validated and figured out somewhere else.
-			return nil, fmt.Errorf("first argument to %q must be a variable", gr.call.Name)
+			return nil, fmt.Errorf("first argument to %q must be a variable", call.Name)
 		}
-		cur, err := createVarRefCursor(t, ref)
+		if err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, cur)
+	} else if gr.regex != nil {
+		cur, err := createRegexCursor(t, gr.regex)
 		if err != nil {
 			return nil, err
 		}
 		cursors = append(cursors, cur)
 	}
 
+	// The tags selected with the fields are kept as columns in the table
+	// of the field instead of being read like a field.
+	var tags []*influxql.VarRef
 	for _, ref := range gr.refs {
+		if ref.Type == influxql.Tag {
+			tags = append(tags, ref)
+			continue
+		}
 		cur, err := createVarRefCursor(t, ref)
 		if err != nil {
 			return nil, err
@@ -135,16 +298,16 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 	// TODO(jsternberg): Establish which variables in the condition are tags and which are fields.
 	// We need to create the references to fields here so they can be joined.
 	var (
-		tags map[influxql.VarRef]struct{}
-		cond influxql.Expr
+		condTags map[influxql.VarRef]struct{}
+		cond     influxql.Expr
 	)
 	valuer := influxql.NowValuer{Now: t.config.Now}
 	if t.stmt.Condition != nil {
 		var err error
-		if cond, _, err = influxql.ConditionExpr(t.stmt.Condition, &valuer); err != nil {
+		if cond, _, err = splitTimeCondition(t.stmt.Condition, &valuer); err != nil {
 			return nil, err
 		} else if cond != nil {
-			tags = make(map[influxql.VarRef]struct{})
+			condTags = make(map[influxql.VarRef]struct{})
 
 			// Walk through the condition for every variable reference. There will be no function
 			// calls here.
@@ -171,8 +334,14 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 				switch typ := t.mapType(ref); typ {
 				case influxql.Tag:
 					// Add this variable name to the listing of tags.
-					tags[*ref] = struct{}{}
+					condTags[*ref] = struct{}{}
 				default:
+					// The fields matching a regex are pivoted into columns
+					// so they cannot be joined with another field.
+					if gr.regex != nil {
+						condErr = errors.New("unimplemented: field regex wildcard with a field condition")
+						return
+					}
 					cur, err := createVarRefCursor(t, ref)
 					if err != nil {
 						condErr = err
@@ -184,57 +353,26 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 		}
 	}
 
-	// Join the cursors using an inner join.
-	// TODO(jsternberg): We need to differentiate between various join types and this needs to be
-	// except: ["_field"] rather than joining on the _measurement. This also needs to specify what the time
-	// column should be.
-	if len(cursors) > 1 {
-		return nil, errors.New("unimplemented: joining fields within a cursor")
-	}
-
-	cur := Join(t, cursors, []string{"_measurement"})
 	if len(tags) > 0 {
-		cur = &tagsCursor{cursor: cur, tags: tags}
+		if len(cursors) == 0 {
+			return nil, errors.New("statement must have at least one field in select clause")
+		} else if len(cursors) > 1 {
+			// The join would rename the tag columns that are in more than one table.
+			return nil, errors.New("unimplemented: tags selected with more than one field")
+		}
+		cursors[0] = &tagColumnsCursor{cursor: cursors[0], tags: tags}
 	}
 
-	// Evaluate the conditional and insert a filter if a condition exists.
-	if cond != nil {
-		// // Generate a filter expression by evaluating the condition and wrapping it in a filter op.
-		expr, err := t.mapField(cond, cur, true)
-		if err != nil {
-			return nil, errors.Wrap(err, "unable to evaluate condition")
-		}
-		cur = &pipeCursor{
-			expr: &ast.PipeExpression{
-				Argument: cur.Expr(),
-				Call: &ast.CallExpression{
-					Callee: &ast.Identifier{
-						Name: "filter",
-					},
-					Arguments: []ast.Expression{
-						&ast.ObjectExpression{
-							Properties: []*ast.Property{{
-								Key: &ast.Identifier{Name: "fn"},
-								Value: &ast.FunctionExpression{
-									Params: []*ast.Property{{
-										Key: &ast.Identifier{Name: "r"},
-									}},
-									Body: expr,
-								},
-							}},
-						},
-					},
-				},
-			},
-			cursor: cur,
-		}
+	// The auxiliary fields for a selector are read separately and joined
+	// with the selected points after the selector has been evaluated.
+	var aux []cursor
+	if gr.call != nil {
+		cursors, aux = cursors[:1], cursors[1:]
 	}
 
-	// Group together the results.
-	if c, err := gr.group(t, cur); err != nil {
+	cur, err := gr.filterAndGroup(t, cursors, condTags, cond)
+	if err != nil {
 		return nil, err
-	} else {
-		cur = c
 	}
 
 	interval, err := t.stmt.GroupByInterval()
@@ -242,33 +380,57 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 		return nil, err
 	}
 
+	// A transformation is of the raw values without a GROUP BY interval
+	// and of an aggregate with one.
+	if transform != nil && interval == 0 {
+		return nil, fmt.Errorf("%s aggregate requires a GROUP BY interval", transform.Name)
+	} else if transform == nil && interval > 0 && call != nil && call.Name == "moving_average" {
+		return nil, fmt.Errorf("aggregate function required inside the call to %s", call.Name)
+	}
+
 	// If a function call is present, evaluate the function call.
-	if gr.call != nil {
-		c, err := createFunctionCursor(t, gr.call, cur, gr.needNormalization || interval > 0)
+	if call != nil {
+		c, err := createFunctionCursor(t, call, cur, gr.needNormalization || interval > 0)
 		if err != nil {
 			return nil, err
 		}
 		cur = c
 
 		// If there was a window operation, we now need to undo that and sort by the start column
-		// so they stay in the same table and are joined in the correct order.
+		// so they stay in the same table and are joined in the correct order. The empty windows
+		// are filled afterwards so fill(previous) can use the value from the previous window.
 		if interval > 0 {
-			cur = &pipeCursor{
-				expr: &ast.PipeExpression{
-					Argument: cur.Expr(),
-					Call: &ast.CallExpression{
-						Callee: &ast.Identifier{Name: "window"},
-						Arguments: []ast.Expression{
-							&ast.ObjectExpression{
-								Properties: []*ast.Property{{
-									Key:   &ast.Identifier{Name: "every"},
-									Value: &ast.Identifier{Name: "inf"},
-								}},
-							},
-						},
-					},
-				},
-				cursor: cur,
+			cur = unwindow(cur)
+			if !influxql.IsSelector(call) {
+				cur = fillWindows(t, call, cur)
+			} else if t.stmt.Fill == influxql.PreviousFill || t.stmt.Fill == influxql.NumberFill {
+				// A selector has no row for an empty window that could be filled.
+				if err := t.ignored(fmt.Sprintf("fill(%s) with a selector", fillName(t.stmt))); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		// The transformation is applied to the filled windows.
+		if transform != nil {
+			if cur, err = createFunctionCursor(t, transform, cur, false); err != nil {
+				return nil, err
+			}
+		}
+
+		// Join the auxiliary fields to the points chosen by the selector.
+		if len(aux) > 0 {
+			// The time of each point is replaced with the window start
+			// so it no longer matches the time of the auxiliary fields.
+			if interval > 0 {
+				return nil, errors.New("unimplemented: auxiliary fields with a selector and a GROUP BY interval")
+			}
+			auxCur, err := gr.filterAndGroup(t, aux, condTags, cond)
+			if err != nil {
+				return nil, err
+			}
+			if cur, err = Join(t, []cursor{cur, auxCur}, t.joinColumns()); err != nil {
+				return nil, err
 			}
 		}
 	} else {
@@ -290,15 +452,184 @@ func (gr *groupInfo) createCursor(t *transpilerState) (cursor, error) {
 	return cur, nil
 }
 
+// filterAndGroup joins the cursors, filters them with the condition,
+// and groups the result.
+func (gr *groupInfo) filterAndGroup(t *transpilerState, cursors []cursor, tags map[influxql.VarRef]struct{}, cond influxql.Expr) (cursor, error) {
+	// Join the cursors using an inner join. Each additional cursor is joined
+	// with the result of the previous join so only two tables are joined at a time.
+	// When joining on the full group key, the cursors are all joined at once.
+	// TODO(jsternberg): We need to differentiate between various join types and this needs to be
+	// except: ["_field"] rather than joining on the _measurement.
+	on := t.joinColumns()
+	cur := cursors[0]
+	if on == nil {
+		var err error
+		if cur, err = Join(t, cursors, on); err != nil {
+			return nil, err
+		}
+	} else {
+		for _, c := range cursors[1:] {
+			var err error
+			if cur, err = Join(t, []cursor{cur, c}, on); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(tags) > 0 {
+		cur = &tagsCursor{cursor: cur, tags: tags}
+	}
+
+	// Evaluate the conditional and insert a filter if a condition exists.
+	if cond != nil {
+		// // Generate a filter expression by evaluating the condition and wrapping it in a filter op.
+		expr, err := t.mapField(cond, cur, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to evaluate condition")
+		}
+		// Combine the condition with the filter on the source when requested
+		// so the rows only need to be filtered once.
+		var filter ast.Expression
+		if t.config.MergeFilters {
+			filter = mergeFilter(cur.Expr(), expr)
+		}
+		if filter == nil {
+			filter = &ast.PipeExpression{
+				Argument: cur.Expr(),
+				Call:     filterCall(expr),
+			}
+		}
+		cur = &pipeCursor{
+			expr:   filter,
+			cursor: cur,
+		}
+	}
+
+	// Group together the results.
+	return gr.group(t, cur)
+}
+
+// fillsEmptyWindows returns true if the empty windows are created so the
+// fill option of the select statement can be applied to them. A selector
+// does not return a row for an empty window so they are only created for
+// the other aggregates.
+func (gr *groupInfo) fillsEmptyWindows(t *transpilerState) bool {
+	if call := gr.aggregate(); call == nil || influxql.IsSelector(call) {
+		return false
+	}
+	switch t.stmt.Fill {
+	case influxql.NullFill, influxql.PreviousFill, influxql.NumberFill:
+		return true
+	}
+	return false
+}
+
+// aggregate returns the call that is evaluated for each window. For a
+// transformation of an aggregate, this is the inner aggregate.
+func (gr *groupInfo) aggregate() *influxql.Call {
+	if gr.call == nil {
+		return nil
+	}
+	if inner, ok := gr.call.Args[0].(*influxql.Call); ok {
+		return inner
+	}
+	return gr.call
+}
+
+// fillWindows fills the values of the empty windows with the fill option
+// from the select statement. With fill(null), the empty windows are left
+// with a null value and, with fill(none), they are not created.
+func fillWindows(t *transpilerState, call *influxql.Call, in cursor) cursor {
+	var arg *ast.Property
+	switch t.stmt.Fill {
+	case influxql.PreviousFill:
+		arg = &ast.Property{
+			Key:   &ast.Identifier{Name: "usePrevious"},
+			Value: &ast.BooleanLiteral{Value: true},
+		}
+	case influxql.NumberFill:
+		// The value must have the same type as the column. Only count()
+		// returns an integer since the fields are assumed to be floats.
+		var value ast.Expression
+		switch v := t.stmt.FillValue.(type) {
+		case int64:
+			if call.Name == "count" {
+				value = &ast.IntegerLiteral{Value: v}
+			} else {
+				value = &ast.FloatLiteral{Value: float64(v)}
+			}
+		case float64:
+			if call.Name == "count" {
+				value = &ast.IntegerLiteral{Value: int64(v)}
+			} else {
+				value = &ast.FloatLiteral{Value: v}
+			}
+		}
+		arg = &ast.Property{
+			Key:   &ast.Identifier{Name: "value"},
+			Value: value,
+		}
+	default:
+		return in
+	}
+
+	args := []*ast.Property{arg}
+	if value, ok := in.Value(call); ok && value != execute.DefaultValueColLabel {
+		args = append([]*ast.Property{{
+			Key:   &ast.Identifier{Name: "column"},
+			Value: &ast.StringLiteral{Value: value},
+		}}, args...)
+	}
+	return &pipeCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "fill"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: args,
+					},
+				},
+			},
+		},
+		cursor: in,
+	}
+}
+
+// unwindow undoes a window operation so all of the windows are
+// placed back into the same table.
+func unwindow(in cursor) cursor {
+	return &pipeCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "window"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key:   &ast.Identifier{Name: "every"},
+							Value: &ast.Identifier{Name: "inf"},
+						}},
+					},
+				},
+			},
+		},
+		cursor: in,
+	}
+}
+
 func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 	var windowEvery time.Duration
-	var windowStart time.Time
+	var windowOffset time.Duration
 	tags := []ast.Expression{
 		&ast.StringLiteral{Value: "_measurement"},
 		&ast.StringLiteral{Value: "_start"},
 		&ast.StringLiteral{Value: "_stop"},
-		&ast.StringLiteral{Value: "_field"},
 	}
+	// The points of every field are counted together by count(*).
+	if call := gr.aggregate(); call == nil || !isWildcardArg(call) {
+		tags = append(tags, &ast.StringLiteral{Value: "_field"})
+	}
+	var derived []*ast.Property
 	if len(t.stmt.Dimensions) > 0 {
 		// Maintain a set of the dimensions we have encountered.
 		// This is so we don't duplicate groupings, but we still maintain the
@@ -331,11 +662,13 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 					return nil, errors.New("multiple time dimensions not allowed")
 				} else {
 					windowEvery = lit.Val
-					var windowOffset time.Duration
 					if len(expr.Args) == 2 {
 						switch lit2 := expr.Args[1].(type) {
 						case *influxql.DurationLiteral:
 							windowOffset = lit2.Val % windowEvery
+							if windowOffset < 0 {
+								windowOffset += windowEvery
+							}
 						case *influxql.TimeLiteral:
 							windowOffset = lit2.Val.Sub(lit2.Val.Truncate(windowEvery))
 						case *influxql.Call:
@@ -365,11 +698,27 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 						default:
 							return nil, errors.New("time dimension offset must be duration or now()")
 						}
-
-						//TODO set windowStart
-						windowStart = time.Unix(0, 0).Add(windowOffset)
 					}
 				}
+			case *influxql.BinaryExpr:
+				// Tags that are concatenated are computed into a new column
+				// that is then used as the group key.
+				name := dimensionColumn(expr)
+				if _, ok := m[name]; ok {
+					continue
+				}
+				value, err := concatTags(expr)
+				if err != nil {
+					return nil, err
+				}
+				derived = append(derived, &ast.Property{
+					Key:   &ast.StringLiteral{Value: name},
+					Value: value,
+				})
+				tags = append(tags, &ast.StringLiteral{
+					Value: name,
+				})
+				m[name] = struct{}{}
 			case *influxql.Wildcard:
 				// Do not add a group call for wildcard, which means group by everything
 				return in, nil
@@ -381,6 +730,39 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 		}
 	}
 
+	// Compute the derived group keys before they are used to group the tables.
+	if len(derived) > 0 {
+		in = &pipeCursor{
+			expr: &ast.PipeExpression{
+				Argument: in.Expr(),
+				Call: &ast.CallExpression{
+					Callee: &ast.Identifier{
+						Name: "map",
+					},
+					Arguments: []ast.Expression{
+						&ast.ObjectExpression{
+							Properties: []*ast.Property{{
+								Key: &ast.Identifier{
+									Name: "fn",
+								},
+								Value: &ast.FunctionExpression{
+									Params: []*ast.Property{{
+										Key: &ast.Identifier{Name: "r"},
+									}},
+									Body: &ast.ObjectExpression{
+										With:       &ast.Identifier{Name: "r"},
+										Properties: derived,
+									},
+								},
+							}},
+						},
+					},
+				},
+			},
+			cursor: in,
+		}
+	}
+
 	// Perform the grouping by the tags we found. There is always a group by because
 	// there is always something to group in influxql.
 	// TODO(jsternberg): A wildcard will skip this step.
@@ -432,9 +814,9 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 								Name: "columns",
 							},
 							Value: &ast.ArrayExpression{
-								Elements: append(tags,
-									&ast.StringLiteral{Value: execute.DefaultTimeColLabel},
-									&ast.StringLiteral{Value: execute.DefaultValueColLabel}),
+								Elements: append(append(tags,
+									&ast.StringLiteral{Value: execute.DefaultTimeColLabel}),
+									valueColumns(in)...),
 							},
 						}},
 					},
@@ -453,13 +835,24 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 				Values: durationLiteral(windowEvery),
 			},
 		}}
-		if !windowStart.IsZero() {
+		if windowOffset != 0 {
+			args = append(args, &ast.Property{
+				Key: &ast.Identifier{
+					Name: "offset",
+				},
+				Value: &ast.DurationLiteral{
+					Values: durationLiteral(windowOffset),
+				},
+			})
+		}
+		// The empty windows are created so the fill option can be applied to them.
+		if gr.fillsEmptyWindows(t) {
 			args = append(args, &ast.Property{
 				Key: &ast.Identifier{
-					Name: "start",
+					Name: "createEmpty",
 				},
-				Value: &ast.DateTimeLiteral{
-					Value: windowStart.UTC(),
+				Value: &ast.BooleanLiteral{
+					Value: true,
 				},
 			})
 		}
@@ -483,6 +876,44 @@ func (gr *groupInfo) group(t *transpilerState, in cursor) (cursor, error) {
 	return in, nil
 }
 
+// concatTags creates the expression that concatenates the tags in a
+// dimension such as GROUP BY host + region.
+func concatTags(expr influxql.Expr) (ast.Expression, error) {
+	switch expr := expr.(type) {
+	case *influxql.ParenExpr:
+		return concatTags(expr.Expr)
+	case *influxql.BinaryExpr:
+		if expr.Op != influxql.ADD {
+			return nil, errors.New("only time and tag dimensions allowed")
+		}
+		lhs, err := concatTags(expr.LHS)
+		if err != nil {
+			return nil, err
+		}
+		rhs, err := concatTags(expr.RHS)
+		if err != nil {
+			return nil, err
+		}
+		return &ast.BinaryExpression{
+			Operator: ast.AdditionOperator,
+			Left:     lhs,
+			Right:    rhs,
+		}, nil
+	case *influxql.VarRef:
+		if strings.ToLower(expr.Val) == "time" {
+			return nil, errors.New("only time and tag dimensions allowed")
+		}
+		return &ast.MemberExpression{
+			Object:   &ast.Identifier{Name: "r"},
+			Property: &ast.StringLiteral{Value: expr.Val},
+		}, nil
+	case *influxql.StringLiteral:
+		return &ast.StringLiteral{Value: expr.Val}, nil
+	default:
+		return nil, errors.New("only time and tag dimensions allowed")
+	}
+}
+
 // tagsCursor is a pseudo-cursor that can be used to access tags within the cursor.
 type tagsCursor struct {
 	cursor
@@ -540,3 +971,90 @@ func durationLiteral(d time.Duration) (dur []ast.Duration) {
 	}
 	return dur
 }
+
+// isWildcardArg returns true if the first argument to the call is a wildcard.
+func isWildcardArg(call *influxql.Call) bool {
+	if len(call.Args) == 0 {
+		return false
+	}
+	_, ok := call.Args[0].(*influxql.Wildcard)
+	return ok
+}
+
+// joinColumns returns the columns that cursors are joined on. Along with
+// the time and measurement, these are the tags in the GROUP BY clause so
+// the values of different groups are not joined with each other. A tag
+// expression is joined on the column computed for it in group. When the
+// tables are grouped by every tag, the columns are not known so nil is
+// returned to join on the full group key.
+func (t *transpilerState) joinColumns() []string {
+	columns := []string{"_time", "_measurement"}
+	m := make(map[string]struct{})
+	for _, d := range t.stmt.Dimensions {
+		var name string
+		switch expr := influxql.Reduce(d.Expr, nil).(type) {
+		case *influxql.VarRef:
+			name = expr.Val
+		case *influxql.BinaryExpr:
+			name = dimensionColumn(expr)
+		case *influxql.Wildcard:
+			return nil
+		default:
+			continue
+		}
+		if _, ok := m[name]; ok {
+			continue
+		}
+		columns = append(columns, name)
+		m[name] = struct{}{}
+	}
+	return columns
+}
+
+// dimensionColumn returns the name of the column that group computes
+// for a tag expression in the GROUP BY clause.
+func dimensionColumn(expr *influxql.BinaryExpr) string {
+	return expr.String()
+}
+
+// valueColumns returns the columns that hold the values for the cursor.
+func valueColumns(cur cursor) []ast.Expression {
+	var columns []ast.Expression
+	m := make(map[string]struct{})
+	for _, k := range cur.Keys() {
+		name, ok := cur.Value(k)
+		if !ok {
+			continue
+		} else if _, ok := m[name]; ok {
+			continue
+		}
+		columns = append(columns, &ast.StringLiteral{Value: name})
+		m[name] = struct{}{}
+	}
+	return columns
+}
+
+// tagColumnsCursor is a cursor that keeps the tags selected in the fields as columns.
+type tagColumnsCursor struct {
+	cursor
+	tags []*influxql.VarRef
+}
+
+func (c *tagColumnsCursor) Keys() []influxql.Expr {
+	keys := append([]influxql.Expr{}, c.cursor.Keys()...)
+	for _, ref := range c.tags {
+		keys = append(keys, ref)
+	}
+	return keys
+}
+
+func (c *tagColumnsCursor) Value(expr influxql.Expr) (string, bool) {
+	if ref, ok := expr.(*influxql.VarRef); ok {
+		for _, tag := range c.tags {
+			if ref == tag {
+				return tag.Val, true
+			}
+		}
+	}
+	return c.cursor.Value(expr)
+}
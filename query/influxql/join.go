@@ -1,9 +1,11 @@
 package influxql
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/influxql"
 )
 
@@ -13,9 +15,13 @@ type joinCursor struct {
 	exprs []influxql.Expr
 }
 
-func Join(t *transpilerState, cursors []cursor, on []string) cursor {
+// Join joins the cursors on the columns. If the columns are nil, the
+// cursors are joined on their full group key.
+func Join(t *transpilerState, cursors []cursor, on []string) (cursor, error) {
 	if len(cursors) == 1 {
-		return cursors[0]
+		return cursors[0], nil
+	} else if on == nil {
+		return joinGroupKey(t, cursors)
 	}
 
 	// Iterate through each cursor and each expression within each cursor to assign them an id.
@@ -77,7 +83,104 @@ func Join(t *transpilerState, cursors []cursor, on []string) cursor {
 		expr:  expr,
 		m:     m,
 		exprs: exprs,
+	}, nil
+}
+
+// joinGroupKey joins the cursors on every column of their group key except
+// for the field. The columns in the group key are not known when grouping
+// by all tags, so the join function cannot be used. Instead, the field of
+// each cursor is renamed to the column the value is joined into, and the
+// values of the cursors are pivoted into those columns.
+func joinGroupKey(t *transpilerState, cursors []cursor) (cursor, error) {
+	var exprs []influxql.Expr
+	m := make(map[influxql.Expr]string)
+	tables := make([]ast.Expression, 0, len(cursors))
+	for _, cur := range cursors {
+		ident := t.assignment(cur.Expr())
+		name := fmt.Sprintf("%s_%s", ident.Name, execute.DefaultValueColLabel)
+		for _, k := range cur.Keys() {
+			// Only the value column is pivoted. Any other column
+			// is not part of the result.
+			if varName, _ := cur.Value(k); varName != execute.DefaultValueColLabel {
+				return nil, errors.New("unimplemented: GROUP BY * with columns other than the value of each field")
+			}
+			exprs = append(exprs, k)
+			m[k] = name
+		}
+		tables = append(tables, &ast.PipeExpression{
+			Argument: &ast.Identifier{Name: ident.Name},
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "map"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key: &ast.Identifier{Name: "fn"},
+							Value: &ast.FunctionExpression{
+								Params: []*ast.Property{{
+									Key: &ast.Identifier{Name: "r"},
+								}},
+								Body: &ast.ObjectExpression{
+									With: &ast.Identifier{Name: "r"},
+									Properties: []*ast.Property{{
+										Key:   &ast.Identifier{Name: "_field"},
+										Value: &ast.StringLiteral{Value: name},
+									}},
+								},
+							},
+						}},
+					},
+				},
+			},
+		})
 	}
+
+	expr := &ast.PipeExpression{
+		Argument: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "union"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key:   &ast.Identifier{Name: "tables"},
+						Value: &ast.ArrayExpression{Elements: tables},
+					}},
+				},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "pivot"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{Name: "rowKey"},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{
+									&ast.StringLiteral{Value: execute.DefaultTimeColLabel},
+								},
+							},
+						},
+						{
+							Key: &ast.Identifier{Name: "columnKey"},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{
+									&ast.StringLiteral{Value: "_field"},
+								},
+							},
+						},
+						{
+							Key:   &ast.Identifier{Name: "valueColumn"},
+							Value: &ast.StringLiteral{Value: execute.DefaultValueColLabel},
+						},
+					},
+				},
+			},
+		},
+	}
+	return &joinCursor{
+		expr:  expr,
+		m:     m,
+		exprs: exprs,
+	}, nil
 }
 
 func (c *joinCursor) Expr() ast.Expression {
@@ -85,10 +188,8 @@ func (c *joinCursor) Expr() ast.Expression {
 }
 
 func (c *joinCursor) Keys() []influxql.Expr {
-	keys := make([]influxql.Expr, 0, len(c.m))
-	for k := range c.m {
-		keys = append(keys, k)
-	}
+	keys := make([]influxql.Expr, len(c.exprs))
+	copy(keys, c.exprs)
 	return keys
 }
 
@@ -2,10 +2,12 @@ package influxql
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/influxql"
 )
 
@@ -40,11 +42,31 @@ func (t *transpilerState) mapFields(in cursor) (cursor, error) {
 		panic("number of columns does not match the number of fields")
 	}
 
+	// The fields matching a regex are pivoted so each field has its own column.
+	if len(t.stmt.Fields) == 1 {
+		if _, ok := t.stmt.Fields[0].Expr.(*influxql.RegexLiteral); ok {
+			return pivotFields(in), nil
+		}
+	}
+
+	// If any of the fields has to be computed, the columns cannot be renamed
+	// and the values have to be evaluated with a map.
+	for _, f := range t.stmt.Fields {
+		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" {
+			continue
+		} else if _, ok := in.Value(f.Expr); !ok {
+			return t.evalFields(in, columns)
+		}
+	}
+
 	properties := make([]*ast.Property, 0, len(t.stmt.Fields))
 	for i, f := range t.stmt.Fields {
 		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" {
 			// Skip past any time columns.
 			continue
+		} else if name, _ := in.Value(f.Expr); name == columns[i] {
+			// Tag columns already have the name of the column.
+			continue
 		}
 		fieldName, err := t.mapField(f.Expr, in, false)
 		if err != nil {
@@ -79,6 +101,113 @@ func (t *transpilerState) mapFields(in cursor) (cursor, error) {
 	}, nil
 }
 
+// pivotFields creates a column for each field with the field name
+// as the column name.
+func pivotFields(in cursor) cursor {
+	return &mapCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "pivot",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{Name: "rowKey"},
+								Value: &ast.ArrayExpression{
+									Elements: []ast.Expression{
+										&ast.StringLiteral{Value: execute.DefaultTimeColLabel},
+									},
+								},
+							},
+							{
+								Key: &ast.Identifier{Name: "columnKey"},
+								Value: &ast.ArrayExpression{
+									Elements: []ast.Expression{
+										&ast.StringLiteral{Value: "_field"},
+									},
+								},
+							},
+							{
+								Key:   &ast.Identifier{Name: "valueColumn"},
+								Value: &ast.StringLiteral{Value: execute.DefaultValueColLabel},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// evalFields will evaluate the expression for each field and map
+// the result to the column name for that field.
+func (t *transpilerState) evalFields(in cursor, columns []string) (cursor, error) {
+	properties := make([]*ast.Property, 0, len(t.stmt.Fields)+1)
+	properties = append(properties, &ast.Property{
+		Key: &ast.Identifier{Name: execute.DefaultTimeColLabel},
+		Value: &ast.MemberExpression{
+			Object:   &ast.Identifier{Name: "r"},
+			Property: &ast.Identifier{Name: execute.DefaultTimeColLabel},
+		},
+	})
+	for i, f := range t.stmt.Fields {
+		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" {
+			// Skip past any time columns.
+			continue
+		}
+		value, err := t.mapField(f.Expr, in, true)
+		if err != nil {
+			return nil, err
+		}
+		properties = append(properties, &ast.Property{
+			Key:   &ast.Identifier{Name: columns[i]},
+			Value: value,
+		})
+	}
+	return &mapCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "map",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{
+									Name: "fn",
+								},
+								Value: &ast.FunctionExpression{
+									Params: []*ast.Property{{
+										Key: &ast.Identifier{
+											Name: "r",
+										},
+									}},
+									Body: &ast.ObjectExpression{
+										Properties: properties,
+									},
+								},
+							},
+							{
+								Key: &ast.Identifier{
+									Name: "mergeKey",
+								},
+								Value: &ast.BooleanLiteral{
+									Value: true,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
 func (t *transpilerState) mapField(expr influxql.Expr, in cursor, returnMemberExpr bool) (ast.Expression, error) {
 	if sym, ok := in.Value(expr); ok {
 		var mappedName ast.Expression
@@ -135,6 +264,13 @@ func (t *transpilerState) mapField(expr influxql.Expr, in cursor, returnMemberEx
 }
 
 func (t *transpilerState) evalBinaryExpr(expr *influxql.BinaryExpr, in cursor) (ast.Expression, error) {
+	// Arithmetic on literals is evaluated when the query is transpiled.
+	if isConstant(expr) {
+		if lit := influxql.Reduce(expr, nil); !isBinaryExpr(lit) {
+			return t.mapField(lit, in, true)
+		}
+	}
+
 	fn := func() func(left, right ast.Expression) ast.Expression {
 		b := evalBuilder{}
 		switch expr.Op {
@@ -154,6 +290,10 @@ func (t *transpilerState) evalBinaryExpr(expr *influxql.BinaryExpr, in cursor) (
 			return b.eval(ast.AdditionOperator)
 		case influxql.SUB:
 			return b.eval(ast.SubtractionOperator)
+		case influxql.MUL:
+			return b.eval(ast.MultiplicationOperator)
+		case influxql.DIV:
+			return b.eval(ast.DivisionOperator)
 		case influxql.AND:
 			return b.logical(ast.AndOperator)
 		case influxql.OR:
@@ -178,9 +318,111 @@ func (t *transpilerState) evalBinaryExpr(expr *influxql.BinaryExpr, in cursor) (
 	if err != nil {
 		return nil, err
 	}
+
+	// Fields are assumed to be floats so any integer literal compared to or
+	// combined with a field needs to be a float literal or flux will report
+	// a type mismatch.
+	if isComparison(expr.Op) || isArithmetic(expr.Op) {
+		if isFieldRef(expr.LHS, in) {
+			rhs = toFloatLiteral(rhs)
+		}
+		if isFieldRef(expr.RHS, in) {
+			lhs = toFloatLiteral(lhs)
+		}
+	}
+	// Tags are always strings so a boolean compared to a tag
+	// is compared with the text of the boolean.
+	if expr.Op == influxql.EQ || expr.Op == influxql.NEQ {
+		if isTagRef(expr.LHS, in) {
+			rhs = toStringLiteral(rhs)
+		}
+		if isTagRef(expr.RHS, in) {
+			lhs = toStringLiteral(lhs)
+		}
+	}
 	return fn(lhs, rhs), nil
 }
 
+// isComparison returns true if the operator compares two values.
+func isComparison(op influxql.Token) bool {
+	switch op {
+	case influxql.EQ, influxql.NEQ, influxql.GT, influxql.GTE, influxql.LT, influxql.LTE:
+		return true
+	}
+	return false
+}
+
+// isArithmetic returns true if the operator is an arithmetic operator.
+func isArithmetic(op influxql.Token) bool {
+	switch op {
+	case influxql.ADD, influxql.SUB, influxql.MUL, influxql.DIV:
+		return true
+	}
+	return false
+}
+
+// isConstant returns true if the expression only contains arithmetic
+// on number literals.
+func isConstant(expr influxql.Expr) bool {
+	switch expr := expr.(type) {
+	case *influxql.BinaryExpr:
+		return isArithmetic(expr.Op) && isConstant(expr.LHS) && isConstant(expr.RHS)
+	case *influxql.ParenExpr:
+		return isConstant(expr.Expr)
+	case *influxql.IntegerLiteral, *influxql.NumberLiteral:
+		return true
+	}
+	return false
+}
+
+// isBinaryExpr returns true if the expression is a binary expression.
+func isBinaryExpr(expr influxql.Expr) bool {
+	_, ok := expr.(*influxql.BinaryExpr)
+	return ok
+}
+
+// isFieldRef returns true if the expression is a variable reference
+// for a field within the cursor.
+func isFieldRef(expr influxql.Expr, in cursor) bool {
+	ref, ok := expr.(*influxql.VarRef)
+	if !ok {
+		return false
+	}
+	// Tags are accessed with their own name while fields
+	// are accessed through a value column.
+	sym, ok := in.Value(ref)
+	return ok && sym != ref.Val
+}
+
+// isTagRef returns true if the expression is a variable reference
+// for a tag within the cursor.
+func isTagRef(expr influxql.Expr, in cursor) bool {
+	ref, ok := expr.(*influxql.VarRef)
+	if !ok {
+		return false
+	}
+	sym, ok := in.Value(ref)
+	return ok && sym == ref.Val
+}
+
+// toFloatLiteral converts an integer literal into a float literal.
+// Any other expression is returned unchanged.
+func toFloatLiteral(expr ast.Expression) ast.Expression {
+	if lit, ok := expr.(*ast.IntegerLiteral); ok {
+		return &ast.FloatLiteral{Value: float64(lit.Value)}
+	}
+	return expr
+}
+
+// toStringLiteral converts a boolean literal into a string literal.
+// Any other expression is returned unchanged.
+func toStringLiteral(expr ast.Expression) ast.Expression {
+	if lit, ok := expr.(*ast.BooleanLiteral); ok {
+		return &ast.StringLiteral{Value: strconv.FormatBool(lit.Value)}
+	}
+	return expr
+}
+
 // evalBuilder is used for namespacing the logical and eval wrapping functions.
 type evalBuilder struct{}
 
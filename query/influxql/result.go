@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/influxdata/flux"
 	"github.com/influxdata/flux/execute"
 	"github.com/influxdata/flux/iocounter"
+	"github.com/influxdata/influxdb/v2/models"
 )
 
 // MultiResultEncoder encodes results as InfluxQL JSON format.
@@ -27,6 +29,8 @@ type MultiResultEncoder struct{}
 //  4.  All other columns are fields and will be output in the order they are found.
 //      TODO(jsternberg): This function currently requires the first column to be a time field, but this isn't
 //      a strict requirement and will be lifted when we begin to work on transpiling meta queries.
+//  5.  A table with a _series column lists series. Each row is encoded as a series key in a single key
+//      column where the _series column is the measurement and the other string columns are the tags.
 func (e *MultiResultEncoder) Encode(w io.Writer, results flux.ResultIterator) (int64, error) {
 	resp := Response{}
 	wc := &iocounter.Writer{Writer: w}
@@ -45,6 +49,15 @@ func (e *MultiResultEncoder) Encode(w io.Writer, results flux.ResultIterator) (i
 
 		result := Result{StatementID: id}
 		if err := tables.Do(func(tbl flux.Table) error {
+			if execute.ColIdx("_series", tbl.Cols()) >= 0 {
+				row, err := encodeSeriesKeys(tbl)
+				if err != nil {
+					return err
+				}
+				result.Series = append(result.Series, row)
+				return nil
+			}
+
 			var row Row
 
 			for j, c := range tbl.Key().Cols() {
@@ -185,6 +198,44 @@ func (e *MultiResultEncoder) Encode(w io.Writer, results flux.ResultIterator) (i
 	err := json.NewEncoder(wc).Encode(resp)
 	return wc.Count(), err
 }
+
+// encodeSeriesKeys encodes the rows of a table with a _series column as
+// sorted series keys.
+func encodeSeriesKeys(tbl flux.Table) (*Row, error) {
+	var keys []string
+	if err := tbl.Do(func(cr flux.ColReader) error {
+		for i := 0; i < cr.Len(); i++ {
+			var name string
+			tags := make(map[string]string)
+			for j, c := range cr.Cols() {
+				if c.Type != flux.TString {
+					continue
+				}
+				vs := cr.Strings(j)
+				if !vs.IsValid(i) {
+					continue
+				}
+				if c.Label == "_series" {
+					name = vs.ValueString(i)
+				} else {
+					tags[c.Label] = vs.ValueString(i)
+				}
+			}
+			keys = append(keys, string(models.MakeKey([]byte(name), models.NewTags(tags))))
+		}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+
+	row := &Row{Columns: []string{"key"}}
+	for _, key := range keys {
+		row.Values = append(row.Values, []interface{}{key})
+	}
+	return row, nil
+}
+
 func NewMultiResultEncoder() *MultiResultEncoder {
 	return new(MultiResultEncoder)
 }
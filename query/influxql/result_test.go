@@ -78,6 +78,28 @@ func TestMultiResultEncoder_Encode(t *testing.T) {
 			),
 			out: `{"results":[{"statement_id":0,"series":[{"columns":["name"],"values":[["telegraf"]]}]}]}`,
 		},
+		{
+			name: "Series Keys",
+			in: flux.NewSliceResultIterator(
+				[]flux.Result{&executetest.Result{
+					Nm: "0",
+					Tbls: []*executetest.Table{{
+						KeyCols: []string{},
+						ColMeta: []flux.ColMeta{
+							{Label: "_series", Type: flux.TString},
+							{Label: "region", Type: flux.TString},
+							{Label: "host", Type: flux.TString},
+						},
+						Data: [][]interface{}{
+							{"mem", nil, nil},
+							{"cpu", "us west", "server01"},
+							{"cpu", nil, "server02"},
+						},
+					}},
+				}},
+			),
+			out: `{"results":[{"statement_id":0,"series":[{"columns":["key"],"values":[["cpu,host=server01,region=us\\ west"],["cpu,host=server02"],["mem"]]}]}]}`,
+		},
 		{
 			name: "Error",
 			in:   &resultErrorIterator{Error: "expected"},
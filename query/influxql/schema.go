@@ -0,0 +1,141 @@
+package influxql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/influxdata/influxql"
+)
+
+// SchemaResolver looks up the schema of a measurement so wildcards and
+// regular expressions in the fields can be expanded.
+type SchemaResolver interface {
+	// FieldKeysForMeasurement returns the names of the fields in the measurement.
+	FieldKeysForMeasurement(ctx context.Context, db, rp, measurement string) ([]string, error)
+}
+
+// schemaMapper implements the influxql.FieldMapper using a SchemaResolver.
+// The field keys for each measurement are only resolved once.
+type schemaMapper struct {
+	ctx      context.Context
+	t        *transpilerState
+	resolver SchemaResolver
+}
+
+func (m *schemaMapper) FieldDimensions(mm *influxql.Measurement) (map[string]influxql.DataType, map[string]struct{}, error) {
+	keys, err := m.fieldKeys(mm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The type of the fields is not known so they are assumed to be floats.
+	fields := make(map[string]influxql.DataType, len(keys))
+	for _, key := range keys {
+		fields[key] = influxql.Float
+	}
+	return fields, nil, nil
+}
+
+func (m *schemaMapper) MapType(mm *influxql.Measurement, field string) influxql.DataType {
+	keys, err := m.fieldKeys(mm)
+	if err != nil {
+		return influxql.Unknown
+	}
+	for _, key := range keys {
+		if key == field {
+			return influxql.Float
+		}
+	}
+	return influxql.Tag
+}
+
+func (m *schemaMapper) fieldKeys(mm *influxql.Measurement) ([]string, error) {
+	if mm.Regex != nil {
+		return nil, errors.New("unimplemented: field wildcard with a measurement regex")
+	}
+
+	db, rp := mm.Database, mm.RetentionPolicy
+	if db == "" {
+		db = m.t.config.DefaultDatabase
+	}
+	if rp == "" {
+		rp = m.t.config.DefaultRetentionPolicy
+	}
+
+	key := db + "/" + rp + "/" + mm.Name
+	if keys, ok := m.t.fieldKeys[key]; ok {
+		return keys, nil
+	}
+	keys, err := m.resolver.FieldKeysForMeasurement(m.ctx, db, rp, mm.Name)
+	if err != nil {
+		return nil, err
+	}
+	m.t.fieldKeys[key] = keys
+	return keys, nil
+}
+
+// expandFields replaces the wildcards and regular expressions in the fields
+// of the select statement with the fields from the SchemaResolver.
+func (t *transpilerState) expandFields(ctx context.Context) error {
+	if t.config.SchemaResolver == nil || !t.stmt.HasFieldWildcard() {
+		return nil
+	}
+
+	stmt, err := t.stmt.RewriteFields(&schemaMapper{
+		ctx:      ctx,
+		t:        t,
+		resolver: t.config.SchemaResolver,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Only the fields are expanded. The dimensions remain as they were written.
+	stmt.Dimensions = t.stmt.Dimensions
+	t.stmt = stmt
+	return nil
+}
+
+// resolveTypes sets the type of the variable references in the fields that
+// do not have one using the SchemaResolver. A reference that is not a field
+// of any of the measurements is a tag.
+func (t *transpilerState) resolveTypes(ctx context.Context) error {
+	if t.config.SchemaResolver == nil {
+		return nil
+	}
+
+	m := &schemaMapper{
+		ctx:      ctx,
+		t:        t,
+		resolver: t.config.SchemaResolver,
+	}
+	var err error
+	influxql.WalkFunc(t.stmt.Fields, func(node influxql.Node) {
+		ref, ok := node.(*influxql.VarRef)
+		if !ok || err != nil || ref.Type != influxql.Unknown || ref.Val == "time" {
+			return
+		}
+
+		typ := influxql.Tag
+		for _, source := range t.stmt.Sources {
+			// The field keys of a measurement regex cannot be resolved
+			// so the type remains unknown.
+			mm, ok := source.(*influxql.Measurement)
+			if !ok || mm.Regex != nil {
+				return
+			}
+
+			var keys []string
+			if keys, err = m.fieldKeys(mm); err != nil {
+				return
+			}
+			for _, key := range keys {
+				if key == ref.Val {
+					typ = influxql.Float
+				}
+			}
+		}
+		ref.Type = typ
+	})
+	return err
+}
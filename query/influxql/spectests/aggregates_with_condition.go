@@ -20,5 +20,26 @@ func init() {
 	|> yield(name: "0")
 `
 		}),
+		NewFixture(
+			`SELECT count(value) FROM db0..cpu WHERE (time >= '2010-09-15T09:00:00Z' AND time < '2010-09-15T10:00:00Z') OR (time >= '2010-09-15T11:00:00Z' AND time < '2010-09-15T12:00:00Z')`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T09:00:00Z, stop: 2010-09-15T10:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T11:00:00Z, stop: 2010-09-15T12:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+union(tables: [t0, t1])
+	|> map(fn: (r) => ({r with _start: 2010-09-15T09:00:00Z, _stop: 2010-09-15T12:00:00Z}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> count()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> rename(columns: {_value: "count"})
+	|> yield(name: "0")
+`,
+		),
 	)
 }
@@ -0,0 +1,53 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT distinct(value) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> distinct()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "distinct"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT distinct value FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> distinct()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "distinct"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT distinct(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m), host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> distinct()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "distinct"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
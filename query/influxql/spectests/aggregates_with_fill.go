@@ -0,0 +1,184 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(none)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(null)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(0)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> fill(value: 0.0)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(2.5)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> fill(value: 2.5)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT count(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(100)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> count()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> fill(value: 100)
+	|> rename(columns: {_value: "count"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m), host fill(previous)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> fill(usePrevious: true)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(none)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(null)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(previous)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m) fill(0)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -19,5 +19,143 @@ func init() {
 	|> yield(name: "0")
 `
 		}),
+		AggregateTest(func(name string) (stmt, want string) {
+			return fmt.Sprintf(`SELECT %s(value) FROM db0..cpu GROUP BY host, region`, name),
+				`package main
+
+` + fmt.Sprintf(`from(bucketID: "%s"`, bucketID.String()) + `)
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host", "region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "_time", "_value"])
+	|> ` + name + `()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "` + name + `"})
+	|> yield(name: "0")
+`
+		}),
+		AggregateTest(func(name string) (stmt, want string) {
+			return fmt.Sprintf(`SELECT %s(value) FROM db0..cpu GROUP BY host, region, dc`, name),
+				`package main
+
+` + fmt.Sprintf(`from(bucketID: "%s"`, bucketID.String()) + `)
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "dc"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "dc", "_time", "_value"])
+	|> ` + name + `()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "` + name + `"})
+	|> yield(name: "0")
+`
+		}),
+		AggregateTest(func(name string) (stmt, want string) {
+			return fmt.Sprintf(`SELECT %s(value) FROM db0..cpu GROUP BY host, region, dc, rack`, name),
+				`package main
+
+` + fmt.Sprintf(`from(bucketID: "%s"`, bucketID.String()) + `)
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "dc", "rack"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "dc", "rack", "_time", "_value"])
+	|> ` + name + `()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "` + name + `"})
+	|> yield(name: "0")
+`
+		}),
+		AggregateTest(func(name string) (stmt, want string) {
+			return fmt.Sprintf(`SELECT %s(value) FROM db0..cpu WHERE time >= now() - 10m GROUP BY host, time(1m)`, name),
+				`package main
+
+` + fmt.Sprintf(`from(bucketID: "%s"`, bucketID.String()) + `)
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> ` + name + `()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "` + name + `"})
+	|> yield(name: "0")
+`
+		}),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 10m GROUP BY time(1m), host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 10m GROUP BY host, time(1m), region`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host", "region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "region", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(a) / mean(b) FROM db0..cpu GROUP BY *`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+union(tables: [t0
+	|> map(fn: (r) => ({r with _field: "t0__value"})), t1
+	|> map(fn: (r) => ({r with _field: "t1__value"}))])
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> map(fn: (r) => ({_time: r._time, mean_mean: r["t0__value"] / r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a + b FROM db0..cpu GROUP BY *`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+
+union(tables: [t0
+	|> map(fn: (r) => ({r with _field: "t0__value"})), t1
+	|> map(fn: (r) => ({r with _field: "t1__value"}))])
+	|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+	|> map(fn: (r) => ({_time: r._time, a_b: r["t0__value"] + r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
 	)
 }
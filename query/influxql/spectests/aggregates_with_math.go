@@ -0,0 +1,166 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT mean(a) + max(b) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> map(fn: (r) => ({_time: r._time, mean_max: r["t0__value"] + r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT sum(a) / count(a) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> sum()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> count()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> map(fn: (r) => ({_time: r._time, sum_count: r["t0__value"] / r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(a) - min(a) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> map(fn: (r) => ({_time: r._time, max_min: r["t0__value"] - r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) + max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(1m)`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> map(fn: (r) => ({_time: r._time, mean_max: r["t0__value"] + r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT min(value) / max(value) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> map(fn: (r) => ({_time: r._time, min_max: r["t0__value"] / r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT min(value) / max(value) FROM db0..cpu WHERE time >= now() - 10m GROUP BY time(1m), host`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 1m)
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 1m)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement", "host"])
+	|> map(fn: (r) => ({_time: r._time, min_max: r["t0__value"] / r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+	)
+}
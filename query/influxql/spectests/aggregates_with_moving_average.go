@@ -0,0 +1,56 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT moving_average(mean(value), 3) FROM db0..cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:55:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> movingAverage(n: 3)
+	|> rename(columns: {_value: "moving_average"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT moving_average(mean(value), 5) FROM db0..cpu WHERE time >= now() - 5m GROUP BY time(1m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:55:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> movingAverage(n: 5)
+	|> rename(columns: {_value: "moving_average"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT moving_average(value, 3) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> movingAverage(n: 3)
+	|> rename(columns: {_value: "moving_average"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,52 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT percentile(value, 75) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> quantile(q: 0.75, method: "exact_selector")
+	|> rename(columns: {_value: "percentile"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT percentile(value, 75.0) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> quantile(q: 0.75, method: "exact_selector")
+	|> rename(columns: {_value: "percentile"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT percentile(value, 95) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> quantile(q: 0.95, method: "exact_selector")
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "percentile"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
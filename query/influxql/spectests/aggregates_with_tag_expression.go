@@ -0,0 +1,81 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu GROUP BY host + region`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> map(fn: (r) => ({r with "host + region": r["host"] + r["region"]}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host + region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host + region", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu GROUP BY host + '-' + region, time(1m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> map(fn: (r) => ({r with "host + '-' + region": r["host"] + "-" + r["region"]}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host + '-' + region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host + '-' + region", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu GROUP BY host, host + region`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> map(fn: (r) => ({r with "host + region": r["host"] + r["region"]}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host", "host + region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "host + region", "_time", "_value"])
+	|> max()
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(a) / mean(b) FROM db0..cpu GROUP BY host + region`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+	|> map(fn: (r) => ({r with "host + region": r["host"] + r["region"]}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host + region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host + region", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+	|> map(fn: (r) => ({r with "host + region": r["host"] + r["region"]}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host + region"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host + region", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement", "host + region"])
+	|> map(fn: (r) => ({_time: r._time, mean_mean: r["t0__value"] / r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+	)
+}
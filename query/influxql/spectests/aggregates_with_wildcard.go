@@ -0,0 +1,164 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixtureWithConfig(`SELECT max(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "max_usage_system", "t1__value": "max_usage_user", "t2__value": "max_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT min(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> min()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "min_usage_system", "t1__value": "min_usage_user", "t2__value": "min_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT first(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> first()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> first()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> first()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "first_usage_system", "t1__value": "first_usage_user", "t2__value": "first_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT last(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> last()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> last()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> last()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "last_usage_system", "t1__value": "last_usage_user", "t2__value": "last_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT mean(*) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m)`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+t2 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "mean_usage_system", "t1__value": "mean_usage_user", "t2__value": "mean_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+	)
+}
@@ -13,7 +13,7 @@ func init() {
 	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
 	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
 	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
-	|> window(every: 1m)
+	|> window(every: 1m, createEmpty: true)
 	|> ` + name + `()
 	|> map(fn: (r) => ({r with _time: r._start}))
 	|> window(every: inf)
@@ -13,7 +13,7 @@ func init() {
 	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
 	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
 	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
-	|> window(every: 5m, start: 1970-01-01T00:02:00Z)
+	|> window(every: 5m, offset: 2m, createEmpty: true)
 	|> ` + name + `()
 	|> map(fn: (r) => ({r with _time: r._start}))
 	|> window(every: inf)
@@ -21,5 +21,75 @@ func init() {
 	|> yield(name: "0")
 `
 		}),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1m GROUP BY time(10s, 5s)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:59:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10s, offset: 5s)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT max(value) FROM db0..cpu WHERE time >= now() - 1m GROUP BY time(10s, -5s)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:59:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10s, offset: 5s)
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(7m, now())`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 7m, offset: 4m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(1m, '2010-09-15T08:59:30Z')`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m, offset: 30s, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+		),
 	)
 }
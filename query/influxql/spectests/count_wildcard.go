@@ -0,0 +1,72 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixtureWithConfig(`SELECT count(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> count()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> count()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> count()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "count_usage_system", "t1__value": "count_usage_user", "t2__value": "count_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixture(
+			`SELECT count(*) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> map(fn: (r) => ({r with _value: 1}))
+	|> drop(columns: ["_field"])
+	|> group(columns: ["_measurement", "_start", "_stop"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_time", "_value"])
+	|> unique(column: "_time")
+	|> count()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "count"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT count(*) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m), host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> map(fn: (r) => ({r with _value: 1}))
+	|> drop(columns: ["_field"])
+	|> group(columns: ["_measurement", "_start", "_stop", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "host", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> unique(column: "_time")
+	|> count()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "count"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,77 @@
+package spectests
+
+import "github.com/influxdata/influxdb/v2/query/influxql"
+
+func init() {
+	RegisterFixture(
+		NewFixtureWithConfig(
+			`SELECT value FROM db0..cpu WHERE host = 'server01'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value" and r["host"] == "server01")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.MergeFilters = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SELECT value FROM db0..cpu WHERE host = 'server01' OR value > 1`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value" and (r["host"] == "server01" or r._value > 1.0))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.MergeFilters = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SELECT mean(value) FROM db0..cpu WHERE host = 'server01' AND time >= now() - 10m GROUP BY time(1m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value" and r["host"] == "server01")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.MergeFilters = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SHOW SERIES ON "db0" FROM cpu WHERE host = 'server01'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu" and r["host"] == "server01")
+	|> drop(columns: ["_start", "_stop", "_field", "_time", "_value"])
+	|> limit(n: 1)
+	|> group(columns: [], mode: "by")
+	|> rename(columns: {_measurement: "_series"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.MergeFilters = true
+			},
+		),
+	)
+}
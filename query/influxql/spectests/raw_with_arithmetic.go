@@ -0,0 +1,172 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT a + b FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t0__value", "t1__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b: r["t0__value"] + r["t1__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a + b + c FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "c")
+
+join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t2_t0__value", "t2_t1__value", "t3__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b_c: r["t2_t0__value"] + r["t2_t1__value"] + r["t3__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a - b * c FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "c")
+
+join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t2_t0__value", "t2_t1__value", "t3__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b_c: r["t2_t0__value"] - r["t2_t1__value"] * r["t3__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a + b - c * d FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "c")
+t4 = join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+t5 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "d")
+
+join(tables: {t4: t4, t5: t5}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t4_t2_t0__value", "t4_t2_t1__value", "t4_t3__value", "t5__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b_c_d: r["t4_t2_t0__value"] + r["t4_t2_t1__value"] - r["t4_t3__value"] * r["t5__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a * b / c + d FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "c")
+t4 = join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+t5 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "d")
+
+join(tables: {t4: t4, t5: t5}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t4_t2_t0__value", "t4_t2_t1__value", "t4_t3__value", "t5__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b_c_d: r["t4_t2_t0__value"] * r["t4_t2_t1__value"] / r["t4_t3__value"] + r["t5__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT a / b / c - d FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "a")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "b")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "c")
+t4 = join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+t5 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "d")
+
+join(tables: {t4: t4, t5: t5}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t4_t2_t0__value", "t4_t2_t1__value", "t4_t3__value", "t5__value"])
+	|> map(fn: (r) => ({_time: r._time, a_b_c_d: r["t4_t2_t0__value"] / r["t4_t2_t1__value"] / r["t4_t3__value"] - r["t5__value"]}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value / (60 * 60) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({_time: r._time, value: r._value / 3600.0}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value * (1 + 2) - 0.5 * 3 FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({_time: r._time, value: r._value * 3.0 - 1.5}), mergeKey: true)
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,67 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE enabled = true`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["enabled"] == "true")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE enabled = false`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["enabled"] == "false")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE enabled != true`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["enabled"] != "true")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE enabled::boolean = true`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "enabled")
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> filter(fn: (r) => r["t1__value"] == true)
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t0__value", "t1__value"])
+	|> rename(columns: {"t0__value": "value"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,33 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time = '2020-01-01T00:00:00Z'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2020-01-01T00:00:00Z, stop: 2020-01-01T00:00:00.000000001Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time = '2020-01-01T00:00:00Z' AND host = 'server01'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2020-01-01T00:00:00Z, stop: 2020-01-01T00:00:00.000000001Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["host"] == "server01")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,132 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE host = 'server01' OR host = 'server02'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["host"] == "server01" or r["host"] == "server02")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE value > 1 OR value < -1`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r._value > 1.0 or r._value < -1.0)
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE host = 'server01' AND (region = 'west' OR value > 1)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> filter(fn: (r) => r["host"] == "server01" and (r["region"] == "west" or r._value > 1.0))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time >= '2010-09-15T09:00:00Z' AND time < '2010-09-15T10:00:00Z' OR time >= '2010-09-15T11:00:00Z' AND time < '2010-09-15T12:00:00Z'`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T09:00:00Z, stop: 2010-09-15T10:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T11:00:00Z, stop: 2010-09-15T12:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+union(tables: [t0, t1])
+	|> map(fn: (r) => ({r with _start: 2010-09-15T09:00:00Z, _stop: 2010-09-15T12:00:00Z}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE host = 'server01' AND (time >= '2010-09-15T09:00:00Z' AND time < '2010-09-15T10:00:00Z' OR time >= '2010-09-15T11:00:00Z' AND time < '2010-09-15T12:00:00Z')`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T09:00:00Z, stop: 2010-09-15T10:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T11:00:00Z, stop: 2010-09-15T12:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+union(tables: [t0, t1])
+	|> map(fn: (r) => ({r with _start: 2010-09-15T09:00:00Z, _stop: 2010-09-15T12:00:00Z}))
+	|> filter(fn: (r) => r["host"] == "server01")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time >= '2010-09-15T09:00:00Z' AND time < '2010-09-15T10:00:00Z' OR time >= '2010-09-15T09:30:00Z' AND time < '2010-09-15T11:00:00Z'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T09:00:00Z, stop: 2010-09-15T11:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time >= '2010-09-15T09:00:00Z' AND time < '2010-09-15T10:00:00Z' OR time >= '2010-09-15T12:00:00Z' AND time < '2010-09-15T11:00:00Z'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T09:00:00Z, stop: 2010-09-15T10:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu WHERE time >= now() - 10m OR time < now() - 30m`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2010-09-15T08:30:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+union(tables: [t0, t1])
+	|> map(fn: (r) => ({r with _start: 1677-09-21T00:12:43.145224194Z, _stop: 2262-04-11T23:47:16.854775806Z}))
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,56 @@
+package spectests
+
+import "fmt"
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT mean(value) INTO result_measurement FROM db0..cpu WHERE time >= now() - 10m GROUP BY time(1m), host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 1m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+	|> set(key: "_measurement", value: "result_measurement")
+	|> to(bucketID: "", tagColumns: ["host"], fieldFn: (r) => ({"mean": r["mean"]}))
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) INTO db0.alternate.result_measurement FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "mean"})
+	|> set(key: "_measurement", value: "result_measurement")
+	|> `+fmt.Sprintf(`to(bucketID: "%s"`, altBucketID.String())+`, tagColumns: [], fieldFn: (r) => ({"mean": r["mean"]}))
+`,
+		),
+		NewFixture(
+			`SELECT max(value) INTO db0.autogen.:MEASUREMENT FROM db0..cpu GROUP BY host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> max()
+	|> rename(columns: {_value: "max"})
+	|> to(bucketID: "", tagColumns: ["host"], fieldFn: (r) => ({"max": r["max"]}))
+`,
+		),
+	)
+}
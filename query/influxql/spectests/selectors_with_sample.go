@@ -0,0 +1,99 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT sample(value, 2) FROM db0..cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 2)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+	|> rename(columns: {_value: "sample"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT sample(value, 10) FROM db0..cpu GROUP BY host`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 10)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+	|> rename(columns: {_value: "sample"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT sample(value, 3) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m)`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> window(every: 10m)
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 3)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+	|> window(every: inf)
+	|> rename(columns: {_value: "sample"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixtureWithConfig(`SELECT sample(*, 2) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 2)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 2)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> map(fn: (r) => ({r with _sample: int(v: r._time) % 2147483647 * 48271 % 2147483647}))
+	|> sort(columns: ["_sample"])
+	|> limit(n: 2)
+	|> sort(columns: ["_time"])
+	|> drop(columns: ["_sample"])
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "sample_usage_system", "t1__value": "sample_usage_user", "t2__value": "sample_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+	)
+}
@@ -0,0 +1,103 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SELECT value FROM db0..cpu GROUP BY host SLIMIT 2`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+t1 = t0
+	|> keep(columns: ["_measurement", "host"])
+	|> group(columns: ["_measurement", "host"], mode: "by")
+	|> limit(n: 1)
+	|> group()
+	|> sort(columns: ["_measurement", "host"])
+	|> limit(n: 2)
+
+join(tables: {t0: t0, keys: t1}, on: ["_measurement", "host"])
+	|> sort(columns: ["_time"])
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu GROUP BY host SLIMIT 2 SOFFSET 1`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+t1 = t0
+	|> keep(columns: ["_measurement", "host"])
+	|> group(columns: ["_measurement", "host"], mode: "by")
+	|> limit(n: 1)
+	|> group()
+	|> sort(columns: ["_measurement", "host"])
+	|> limit(n: 2, offset: 1)
+
+join(tables: {t0: t0, keys: t1}, on: ["_measurement", "host"])
+	|> sort(columns: ["_time"])
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m), host LIMIT 3 SLIMIT 2`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> window(every: 10m, createEmpty: true)
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> window(every: inf)
+	|> rename(columns: {_value: "mean"})
+t1 = t0
+	|> keep(columns: ["_measurement", "host"])
+	|> group(columns: ["_measurement", "host"], mode: "by")
+	|> limit(n: 1)
+	|> group()
+	|> sort(columns: ["_measurement", "host"])
+	|> limit(n: 2)
+
+join(tables: {t0: t0, keys: t1}, on: ["_measurement", "host"])
+	|> sort(columns: ["_time"])
+	|> limit(n: 3)
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SELECT value FROM db0..cpu GROUP BY host ORDER BY time DESC SLIMIT 1`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> rename(columns: {_value: "value"})
+t1 = t0
+	|> keep(columns: ["_measurement", "host"])
+	|> group(columns: ["_measurement", "host"], mode: "by")
+	|> limit(n: 1)
+	|> group()
+	|> sort(columns: ["_measurement", "host"])
+	|> limit(n: 1)
+
+join(tables: {t0: t0, keys: t1}, on: ["_measurement", "host"])
+	|> sort(columns: ["_time"], desc: true)
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -0,0 +1,120 @@
+package spectests
+
+import "github.com/influxdata/influxdb/v2/query/influxql"
+
+func init() {
+	RegisterFixture(
+		NewFixtureWithConfig(
+			`SELECT mean(value), stddev(value) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> stddev()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t1__value": "mean", "t2__value": "stddev"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.OptimizeDuplicateSources = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SELECT mean(value), stddev(usage) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage")
+t2 = t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t3 = t1
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> stddev()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t2__value": "mean", "t3__value": "stddev"})
+	|> yield(name: "0")
+`,
+			func(config *influxql.Config) {
+				config.OptimizeDuplicateSources = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SELECT mean(value) FROM db0..cpu; SELECT max(value) FROM db0..cpu`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "1")
+`,
+			func(config *influxql.Config) {
+				config.ShareSources = true
+			},
+		),
+		NewFixtureWithConfig(
+			`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h; SELECT max(value) FROM db0..cpu WHERE time >= now() - 10m`,
+			`package main
+
+t0 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:00:00Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+t0
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: r._start}))
+	|> rename(columns: {_value: "mean"})
+	|> yield(name: "0")
+
+t1 = from(bucketID: "")
+	|> range(start: 2010-09-15T08:50:00Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+t1
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> max()
+	|> rename(columns: {_value: "max"})
+	|> yield(name: "1")
+`,
+			func(config *influxql.Config) {
+				config.ShareSources = true
+			},
+		),
+	)
+}
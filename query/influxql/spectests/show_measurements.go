@@ -0,0 +1,81 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SHOW MEASUREMENTS ON "db0"`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")
+	|> sort()
+	|> rename(columns: {_value: "name"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW MEASUREMENTS ON "db0" WITH MEASUREMENT =~ /cpu.*/`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement =~ /cpu.*/)
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")
+	|> sort()
+	|> rename(columns: {_value: "name"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW MEASUREMENTS ON "db0" WHERE _name !~ /cpu.*/`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement !~ /cpu.*/)
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")
+	|> sort()
+	|> rename(columns: {_value: "name"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW MEASUREMENTS ON "db0" WITH MEASUREMENT = cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")
+	|> sort()
+	|> rename(columns: {_value: "name"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW MEASUREMENTS ON "db0" WITH MEASUREMENT =~ /cpu.*/ WHERE host = 'server01'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement =~ /cpu.*/)
+	|> filter(fn: (r) => r["host"] == "server01")
+	|> keep(columns: ["_measurement"])
+	|> group()
+	|> distinct(column: "_measurement")
+	|> sort()
+	|> rename(columns: {_value: "name"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
@@ -15,6 +15,21 @@ v1.databases()
 	|> set(key: "replicaN", value: "2")
 	|> keep(columns: ["name", "duration", "shardGroupDuration", "replicaN", "default"])
 	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW RETENTION POLICIES`,
+			`package main
+
+import v1 "influxdata/influxdb/v1"
+
+v1.databases()
+	|> filter(fn: (r) => r.databaseName == "db0")
+	|> rename(columns: {retentionPolicy: "name", retentionPeriod: "duration"})
+	|> set(key: "shardGroupDuration", value: "0")
+	|> set(key: "replicaN", value: "2")
+	|> keep(columns: ["name", "duration", "shardGroupDuration", "replicaN", "default"])
+	|> yield(name: "0")
 `,
 		),
 	)
@@ -0,0 +1,48 @@
+package spectests
+
+func init() {
+	RegisterFixture(
+		NewFixture(
+			`SHOW SERIES ON "db0"`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> drop(columns: ["_start", "_stop", "_field", "_time", "_value"])
+	|> limit(n: 1)
+	|> group(columns: [], mode: "by")
+	|> rename(columns: {_measurement: "_series"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW SERIES ON "db0" FROM cpu`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> drop(columns: ["_start", "_stop", "_field", "_time", "_value"])
+	|> limit(n: 1)
+	|> group(columns: [], mode: "by")
+	|> rename(columns: {_measurement: "_series"})
+	|> yield(name: "0")
+`,
+		),
+		NewFixture(
+			`SHOW SERIES ON "db0" FROM cpu WHERE host = 'server01'`,
+			`package main
+
+from(bucketID: "")
+	|> range(start: -1h)
+	|> filter(fn: (r) => r._measurement == "cpu")
+	|> filter(fn: (r) => r["host"] == "server01")
+	|> drop(columns: ["_start", "_stop", "_field", "_time", "_value"])
+	|> limit(n: 1)
+	|> group(columns: [], mode: "by")
+	|> rename(columns: {_measurement: "_series"})
+	|> yield(name: "0")
+`,
+		),
+	)
+}
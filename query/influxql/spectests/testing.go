@@ -2,6 +2,7 @@ package spectests
 
 import (
 	"context"
+	"encoding/json"
 	"path/filepath"
 	"runtime"
 	"testing"
@@ -51,11 +52,16 @@ func init() {
 // Fixture is a structure that will run tests.
 type Fixture interface {
 	Run(t *testing.T)
+
+	// RunJSONRoundtrip verifies the transpiled package is
+	// the same after it is encoded to JSON and decoded.
+	RunJSONRoundtrip(t *testing.T)
 }
 
 type fixture struct {
-	stmt string
-	want string
+	stmt   string
+	want   string
+	config func(config *influxql.Config)
 
 	file string
 	line int
@@ -71,11 +77,20 @@ func NewFixture(stmt, want string) Fixture {
 	}
 }
 
-func (f *fixture) Run(t *testing.T) {
-	organizationID = platformtesting.MustIDBase16("aaaaaaaaaaaaaaaa")
-	bucketID = platformtesting.MustIDBase16("bbbbbbbbbbbbbbbb")
-	altBucketID = platformtesting.MustIDBase16("cccccccccccccccc")
+// NewFixtureWithConfig creates a fixture that modifies the default
+// transpiler configuration before the statement is transpiled.
+func NewFixtureWithConfig(stmt, want string, fn func(config *influxql.Config)) Fixture {
+	_, file, line, _ := runtime.Caller(1)
+	return &fixture{
+		stmt:   stmt,
+		want:   want,
+		config: fn,
+		file:   filepath.Base(file),
+		line:   line,
+	}
+}
 
+func (f *fixture) Run(t *testing.T) {
 	t.Run(f.stmt, func(t *testing.T) {
 		wantAST := parser.ParseSource(f.want)
 		if ast.Check(wantAST) > 0 {
@@ -84,15 +99,7 @@ func (f *fixture) Run(t *testing.T) {
 		}
 		want := ast.Format(wantAST)
 
-		transpiler := influxql.NewTranspilerWithConfig(
-			dbrpMappingSvc,
-			influxql.Config{
-				DefaultDatabase: "db0",
-				Cluster:         "cluster",
-				Now:             Now(),
-			},
-		)
-		pkg, err := transpiler.Transpile(context.Background(), f.stmt)
+		pkg, err := f.transpile()
 		if err != nil {
 			t.Fatalf("%s:%d: unexpected error: %s", f.file, f.line, err)
 		}
@@ -106,6 +113,48 @@ func (f *fixture) Run(t *testing.T) {
 	})
 }
 
+func (f *fixture) RunJSONRoundtrip(t *testing.T) {
+	t.Run(f.stmt, func(t *testing.T) {
+		pkg, err := f.transpile()
+		if err != nil {
+			t.Fatalf("%s:%d: unexpected error: %s", f.file, f.line, err)
+		}
+
+		data, err := json.Marshal(pkg)
+		if err != nil {
+			t.Fatalf("%s:%d: unable to encode the package: %s", f.file, f.line, err)
+		}
+		var decoded ast.Package
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			t.Fatalf("%s:%d: unable to decode the package: %s", f.file, f.line, err)
+		}
+
+		if want, got := ast.Format(pkg), ast.Format(&decoded); want != got {
+			out := diff.LineDiff(want, got)
+			t.Fatalf("unexpected ast after decoding at %s:%d\n%s", f.file, f.line, out)
+		}
+	})
+}
+
+// transpile transpiles the statement with the default transpiler configuration
+// and the modifications of the fixture.
+func (f *fixture) transpile() (*ast.Package, error) {
+	organizationID = platformtesting.MustIDBase16("aaaaaaaaaaaaaaaa")
+	bucketID = platformtesting.MustIDBase16("bbbbbbbbbbbbbbbb")
+	altBucketID = platformtesting.MustIDBase16("cccccccccccccccc")
+
+	config := influxql.Config{
+		DefaultDatabase: "db0",
+		Cluster:         "cluster",
+		Now:             Now(),
+	}
+	if f.config != nil {
+		f.config(&config)
+	}
+	transpiler := influxql.NewTranspilerWithConfig(dbrpMappingSvc, config)
+	return transpiler.Transpile(context.Background(), f.stmt)
+}
+
 type collection struct {
 	stmts []string
 	wants []string
@@ -131,6 +180,18 @@ func (c *collection) Run(t *testing.T) {
 	}
 }
 
+func (c *collection) RunJSONRoundtrip(t *testing.T) {
+	for i, stmt := range c.stmts {
+		f := fixture{
+			stmt: stmt,
+			want: c.wants[i],
+			file: c.file,
+			line: c.line,
+		}
+		f.RunJSONRoundtrip(t)
+	}
+}
+
 var allFixtures []Fixture
 
 func RegisterFixture(fixtures ...Fixture) {
@@ -0,0 +1,110 @@
+package spectests
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/query/influxql"
+)
+
+// fieldKeys is a SchemaResolver that returns the fields from a static mapping.
+type fieldKeys map[string][]string
+
+func (f fieldKeys) FieldKeysForMeasurement(ctx context.Context, db, rp, measurement string) ([]string, error) {
+	return f[measurement], nil
+}
+
+func withFieldKeys(config *influxql.Config) {
+	config.SchemaResolver = fieldKeys{
+		"cpu": {"usage_user", "usage_system", "value"},
+	}
+}
+
+func init() {
+	RegisterFixture(
+		NewFixtureWithConfig(`SELECT * FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+t2 = join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+t3 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+
+join(tables: {t2: t2, t3: t3}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t2_t0__value", "t2_t1__value", "t3__value"])
+	|> rename(columns: {"t2_t0__value": "usage_system", "t2_t1__value": "usage_user", "t3__value": "value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT /usage/ FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement"])
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "t0__value", "t1__value"])
+	|> rename(columns: {"t0__value": "usage_system", "t1__value": "usage_user"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT mean(*) FROM db0..cpu`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t2 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "value")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "_time", "_value"])
+	|> mean()
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1, t2: t2}, on: ["_time", "_measurement"])
+	|> rename(columns: {"t0__value": "mean_usage_system", "t1__value": "mean_usage_user", "t2__value": "mean_value"})
+	|> yield(name: "0")
+`, withFieldKeys),
+		NewFixtureWithConfig(`SELECT max(/usage/) FROM db0..cpu GROUP BY host`, `package main
+
+t0 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_system")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+t1 = from(bucketID: "")
+	|> range(start: 1677-09-21T00:12:43.145224194Z, stop: 2262-04-11T23:47:16.854775806Z)
+	|> filter(fn: (r) => r._measurement == "cpu" and r._field == "usage_user")
+	|> group(columns: ["_measurement", "_start", "_stop", "_field", "host"], mode: "by")
+	|> keep(columns: ["_measurement", "_start", "_stop", "_field", "host", "_time", "_value"])
+	|> max()
+	|> drop(columns: ["_time"])
+	|> map(fn: (r) => ({r with _time: 1970-01-01T00:00:00Z}))
+
+join(tables: {t0: t0, t1: t1}, on: ["_time", "_measurement", "host"])
+	|> rename(columns: {"t0__value": "max_usage_system", "t1__value": "max_usage_user"})
+	|> yield(name: "0")
+`, withFieldKeys),
+	)
+}
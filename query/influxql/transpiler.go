@@ -3,7 +3,10 @@ package influxql
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -13,41 +16,199 @@ import (
 	"github.com/influxdata/influxql"
 )
 
-// Transpiler converts InfluxQL queries into a query spec.
-type Transpiler struct {
-	Config         *Config
+// Transpiler converts InfluxQL queries into a Flux AST package.
+type Transpiler interface {
+	// Transpile parses the InfluxQL query text and converts it into
+	// an equivalent Flux AST package.
+	Transpile(ctx context.Context, txt string) (*ast.Package, error)
+}
+
+// TranspileFunc transpiles the InfluxQL query text into a Flux AST package.
+type TranspileFunc func(ctx context.Context, q string) (*ast.Package, error)
+
+// TranspileResult is the result of transpiling a query with TranspileVerbose.
+type TranspileResult struct {
+	// Package is the transpiled Flux AST package.
+	Package *ast.Package
+	// Statements are the InfluxQL statements parsed from the query text.
+	Statements influxql.Statements
+	// Warnings are the warnings reported while transpiling.
+	Warnings []Warning
+}
+
+// defaultTranspiler is the Transpiler returned by NewTranspiler and NewTranspilerWithConfig.
+type defaultTranspiler struct {
+	config         *Config
 	dbrpMappingSvc influxdb.DBRPMappingServiceV2
 }
 
-func NewTranspiler(dbrpMappingSvc influxdb.DBRPMappingServiceV2) *Transpiler {
+func NewTranspiler(dbrpMappingSvc influxdb.DBRPMappingServiceV2) Transpiler {
 	return NewTranspilerWithConfig(dbrpMappingSvc, Config{})
 }
 
-func NewTranspilerWithConfig(dbrpMappingSvc influxdb.DBRPMappingServiceV2, cfg Config) *Transpiler {
-	return &Transpiler{
-		Config:         &cfg,
+func NewTranspilerWithConfig(dbrpMappingSvc influxdb.DBRPMappingServiceV2, cfg Config) Transpiler {
+	return &defaultTranspiler{
+		config:         &cfg,
 		dbrpMappingSvc: dbrpMappingSvc,
 	}
 }
 
-func (t *Transpiler) Transpile(ctx context.Context, txt string) (*ast.Package, error) {
+func (t *defaultTranspiler) Transpile(ctx context.Context, txt string) (*ast.Package, error) {
+	transpile := t.transpileText
+	if t.config.Middleware != nil {
+		transpile = t.config.Middleware(transpile)
+	}
+	return transpile(ctx, txt)
+}
+
+// transpileText is the TranspileFunc wrapped by the Middleware.
+func (t *defaultTranspiler) transpileText(ctx context.Context, txt string) (*ast.Package, error) {
+	res, err := t.transpileVerbose(ctx, txt)
+	if err != nil {
+		return nil, err
+	}
+	return res.Package, nil
+}
+
+func (t *defaultTranspiler) transpileVerbose(ctx context.Context, txt string) (*TranspileResult, error) {
 	// Parse the text of the query.
 	q, err := influxql.ParseQuery(txt)
 	if err != nil {
 		return nil, err
 	}
 
-	transpiler := newTranspilerState(t.dbrpMappingSvc, t.Config)
-	for i, s := range q.Statements {
+	// Only use the cache when the result does not change with the current
+	// time, with the time range of the data, or with the schema and the
+	// names returned by the functions in the config.
+	cache := t.config.Cache
+	if cache == nil || (t.config.Now.IsZero() && dependsOnNow(q)) || !t.cacheable() {
+		return t.transpile(ctx, q.Statements)
+	}
+
+	// Return a copy of the cached result so the caller cannot modify the cache.
+	// The warnings are reported again as if the query was transpiled.
+	key := cacheKey(q)
+	if cached, ok := cache.Get(key); ok {
+		res := copyResult(cached)
+		res.Statements = q.Statements
+		if t.config.OnWarning != nil {
+			for _, w := range res.Warnings {
+				t.config.OnWarning(w)
+			}
+		}
+		return res, nil
+	}
+	res, err := t.transpile(ctx, q.Statements)
+	if err != nil {
+		return nil, err
+	}
+	cache.Set(key, copyResult(res))
+	return res, nil
+}
+
+// cacheable returns true if the transpiled query only depends on the
+// query text and the config. The functions that look up the schema, the
+// buckets, or the time range of the data may return a different result
+// each time they are called.
+func (t *defaultTranspiler) cacheable() bool {
+	return t.config.SchemaResolver == nil &&
+		t.config.BucketFederationFn == nil &&
+		t.config.ImplicitTimeRange == nil &&
+		t.config.IdentifierFn == nil
+}
+
+// TranspileVerbose transpiles the InfluxQL query text with the transpiler
+// and returns the parsed statements and the warnings with the package.
+// Only a transpiler created by NewTranspiler or NewTranspilerWithConfig
+// reports warnings.
+func TranspileVerbose(ctx context.Context, t Transpiler, txt string) (*TranspileResult, error) {
+	if t, ok := t.(*defaultTranspiler); ok {
+		return t.transpileVerbose(ctx, txt)
+	}
+	q, err := influxql.ParseQuery(txt)
+	if err != nil {
+		return nil, err
+	}
+	pkg, err := t.Transpile(ctx, txt)
+	if err != nil {
+		return nil, err
+	}
+	return &TranspileResult{
+		Package:    pkg,
+		Statements: q.Statements,
+	}, nil
+}
+
+// TranspileReader reads the InfluxQL query text from the reader and
+// transpiles it with the transpiler.
+func TranspileReader(ctx context.Context, t Transpiler, r io.Reader) (*ast.Package, error) {
+	txt, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return t.Transpile(ctx, string(txt))
+}
+
+// TranspileStatement transpiles an already parsed InfluxQL statement with
+// the transpiler. A transpiler created by NewTranspiler or
+// NewTranspilerWithConfig uses the statement without formatting it as text.
+func TranspileStatement(ctx context.Context, t Transpiler, stmt influxql.Statement) (*ast.Package, error) {
+	if t, ok := t.(*defaultTranspiler); ok {
+		res, err := t.transpile(ctx, influxql.Statements{stmt})
+		if err != nil {
+			return nil, err
+		}
+		return res.Package, nil
+	}
+	return t.Transpile(ctx, stmt.String())
+}
+
+// TranspileToFlux transpiles the InfluxQL query text with the transpiler
+// and formats the package as Flux source code.
+func TranspileToFlux(ctx context.Context, t Transpiler, txt string) (string, error) {
+	pkg, err := t.Transpile(ctx, txt)
+	if err != nil {
+		return "", err
+	}
+	return ast.Format(pkg), nil
+}
+
+// TranspileWithTimeout transpiles the InfluxQL query text with the transpiler
+// and stops with context.DeadlineExceeded if it takes longer than the timeout.
+func TranspileWithTimeout(t Transpiler, txt string, timeout time.Duration) (*ast.Package, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return t.Transpile(ctx, txt)
+}
+
+func (t *defaultTranspiler) transpile(ctx context.Context, stmts influxql.Statements) (res *TranspileResult, err error) {
+	// A panic is a bug in the transpiler. Report it as an error
+	// instead of bringing down the caller.
+	defer func() {
+		if r := recover(); r != nil {
+			res, err = nil, fmt.Errorf("internal error: %v", r)
+		}
+	}()
+
+	transpiler := newTranspilerState(t.dbrpMappingSvc, t.config)
+	for i, s := range stmts {
+		// Stop before the next statement if the context has been canceled.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		if err := transpiler.Transpile(ctx, i, s); err != nil {
 			return nil, err
 		}
 	}
-	return &ast.Package{
-		Package: "main",
-		Files: []*ast.File{
-			transpiler.file,
+	return &TranspileResult{
+		Package: &ast.Package{
+			Package: "main",
+			Files: []*ast.File{
+				transpiler.file,
+			},
 		},
+		Statements: stmts,
+		Warnings:   transpiler.warnings,
 	}, nil
 }
 
@@ -56,6 +217,11 @@ type transpilerState struct {
 	config         Config
 	file           *ast.File
 	assignments    map[string]ast.Expression
+	sources        map[string]*ast.Identifier
+	fieldKeys      map[string][]string
+	timeRanges     map[string]influxql.TimeRange
+	subqueries     map[*influxql.SubQuery]*ast.Identifier
+	warnings       []Warning
 	dbrpMappingSvc influxdb.DBRPMappingServiceV2
 }
 
@@ -69,6 +235,9 @@ func newTranspilerState(dbrpMappingSvc influxdb.DBRPMappingServiceV2, config *Co
 			},
 		},
 		assignments:    make(map[string]ast.Expression),
+		fieldKeys:      make(map[string][]string),
+		timeRanges:     make(map[string]influxql.TimeRange),
+		subqueries:     make(map[*influxql.SubQuery]*ast.Identifier),
 		dbrpMappingSvc: dbrpMappingSvc,
 	}
 	if config != nil {
@@ -76,7 +245,11 @@ func newTranspilerState(dbrpMappingSvc influxdb.DBRPMappingServiceV2, config *Co
 	}
 	if state.config.Now.IsZero() {
 		// Stamp the current time using the now time.
-		state.config.Now = time.Now()
+		if state.config.NowFn != nil {
+			state.config.Now = state.config.NowFn()
+		} else {
+			state.config.Now = time.Now()
+		}
 	}
 	return state
 }
@@ -86,6 +259,16 @@ func (t *transpilerState) Transpile(ctx context.Context, id int, s influxql.Stat
 	if err != nil {
 		return err
 	}
+
+	// The results of a statement with an INTO clause are written
+	// to the target instead of being returned.
+	if stmt, ok := s.(*influxql.SelectStatement); ok && stmt.Target != nil {
+		t.file.Body = append(t.file.Body, &ast.ExpressionStatement{
+			Expression: expr,
+		})
+		return t.checkOperations()
+	}
+
 	t.file.Body = append(t.file.Body, &ast.ExpressionStatement{
 		Expression: &ast.PipeExpression{
 			Argument: expr,
@@ -106,9 +289,32 @@ func (t *transpilerState) Transpile(ctx context.Context, id int, s influxql.Stat
 			},
 		},
 	})
+	return t.checkOperations()
+}
+
+// checkOperations verifies the transpiled query does not exceed the
+// maximum number of operations.
+func (t *transpilerState) checkOperations() error {
+	if n := t.config.MaxOperationsN; n > 0 && countOperations(t.file) > n {
+		return &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "query too complex: exceeded maximum operation count",
+		}
+	}
 	return nil
 }
 
+// countOperations returns the number of function calls within the node.
+func countOperations(node ast.Node) int {
+	n := 0
+	ast.Walk(ast.CreateVisitor(func(node ast.Node) {
+		if _, ok := node.(*ast.CallExpression); ok {
+			n++
+		}
+	}), node)
+	return n
+}
+
 func (t *transpilerState) transpile(ctx context.Context, s influxql.Statement) (ast.Expression, error) {
 	switch stmt := s.(type) {
 	case *influxql.SelectStatement:
@@ -119,122 +325,104 @@ func (t *transpilerState) transpile(ctx context.Context, s influxql.Statement) (
 		return cur.Expr(), nil
 	case *influxql.ShowTagValuesStatement:
 		return t.transpileShowTagValues(ctx, stmt)
+	case *influxql.ShowSeriesStatement:
+		return t.transpileShowSeries(ctx, stmt)
+	case *influxql.ShowSeriesCardinalityStatement:
+		return t.transpileShowSeriesCardinality(ctx, stmt)
+	case *influxql.ShowMeasurementsStatement:
+		return t.transpileShowMeasurements(ctx, stmt)
 	case *influxql.ShowDatabasesStatement:
 		return t.transpileShowDatabases(ctx, stmt)
 	case *influxql.ShowRetentionPoliciesStatement:
 		return t.transpileShowRetentionPolicies(ctx, stmt)
+	case *influxql.ExplainStatement:
+		return t.transpileExplain(ctx, stmt)
 	default:
 		return nil, fmt.Errorf("unknown statement type %T", s)
 	}
 }
 
-func (t *transpilerState) transpileShowTagValues(ctx context.Context, stmt *influxql.ShowTagValuesStatement) (ast.Expression, error) {
-	// While the ShowTagValuesStatement contains a sources section and those sources are measurements, they do
-	// not actually contain the database and we do not factor in retention policies. So we are always going to use
-	// the default retention policy when evaluating which bucket we are querying and we do not have to consult
-	// the sources in the statement.
-	if stmt.Database == "" {
-		if t.config.DefaultDatabase == "" {
-			return nil, errDatabaseNameRequired
-		}
-		stmt.Database = t.config.DefaultDatabase
+// transpileExplain returns the Flux script for the statement being explained
+// as a single row instead of executing it.
+func (t *transpilerState) transpileExplain(ctx context.Context, stmt *influxql.ExplainStatement) (ast.Expression, error) {
+	if stmt.Analyze {
+		return nil, errors.New("unimplemented: EXPLAIN ANALYZE")
 	}
 
-	expr, err := t.from(&influxql.Measurement{Database: stmt.Database})
-	if err != nil {
+	// The statement is transpiled into its own file so the variables
+	// it assigns are not added to this one.
+	state := newTranspilerState(t.dbrpMappingSvc, &t.config)
+	if err := state.Transpile(ctx, 0, stmt.Statement); err != nil {
 		return nil, err
 	}
+	plan := ast.Format(&ast.Package{
+		Package: "main",
+		Files: []*ast.File{
+			state.file,
+		},
+	})
 
-	// TODO(jsternberg): Read the range from the condition expression. 1.x doesn't actually do this so it isn't
-	// urgent to implement this functionality so we can use the default range.
-	expr = &ast.PipeExpression{
-		Argument: expr,
-		Call: &ast.CallExpression{
-			Callee: &ast.Identifier{
-				Name: "range",
+	array := t.requireImport("experimental/array")
+	return &ast.CallExpression{
+		Callee: &ast.MemberExpression{
+			Object: array,
+			Property: &ast.Identifier{
+				Name: "from",
 			},
-			Arguments: []ast.Expression{
-				&ast.ObjectExpression{
-					Properties: []*ast.Property{
-						{
-							Key: &ast.Identifier{
-								Name: "start",
-							},
-							Value: &ast.DurationLiteral{
-								Values: []ast.Duration{{
-									Magnitude: -1,
-									Unit:      "h",
+		},
+		Arguments: []ast.Expression{
+			&ast.ObjectExpression{
+				Properties: []*ast.Property{{
+					Key: &ast.Identifier{
+						Name: "rows",
+					},
+					Value: &ast.ArrayExpression{
+						Elements: []ast.Expression{
+							&ast.ObjectExpression{
+								Properties: []*ast.Property{{
+									Key:   &ast.StringLiteral{Value: "QUERY PLAN"},
+									Value: &ast.StringLiteral{Value: plan},
 								}},
 							},
 						},
 					},
-				},
+				}},
 			},
 		},
+	}, nil
+}
+
+func (t *transpilerState) transpileShowTagValues(ctx context.Context, stmt *influxql.ShowTagValuesStatement) (ast.Expression, error) {
+	// While the ShowTagValuesStatement contains a sources section and those sources are measurements, they do
+	// not actually contain the database and we do not factor in retention policies. So we are always going to use
+	// the default retention policy when evaluating which bucket we are querying and we do not have to consult
+	// the sources in the statement.
+	db := stmt.Database
+	if db == "" {
+		if t.config.DefaultDatabase == "" {
+			return nil, errDatabaseNameRequired
+		}
+		db = t.config.DefaultDatabase
 	}
 
-	// If we have a list of sources, look through it and add each of the measurement names.
-	measurementNames := make([]string, 0, len(stmt.Sources))
-	for _, source := range stmt.Sources {
-		mm := source.(*influxql.Measurement)
-		measurementNames = append(measurementNames, mm.Name)
+	// TODO(jsternberg): Read the range from the condition expression. 1.x doesn't actually do this so it isn't
+	// urgent to implement this functionality so we can use the default range.
+	expr, err := t.from(&influxql.Measurement{Database: db}, defaultRange)
+	if err != nil {
+		return nil, err
 	}
 
-	if len(measurementNames) > 0 {
-		var filterExpr ast.Expression = &ast.BinaryExpression{
-			Operator: ast.EqualOperator,
-			Left: &ast.MemberExpression{
-				Object:   &ast.Identifier{Name: "r"},
-				Property: &ast.Identifier{Name: "_measurement"},
-			},
-			Right: &ast.StringLiteral{
-				Value: measurementNames[len(measurementNames)-1],
-			},
-		}
-		for i := len(measurementNames) - 2; i >= 0; i-- {
-			filterExpr = &ast.LogicalExpression{
-				Operator: ast.OrOperator,
-				Left: &ast.BinaryExpression{
-					Operator: ast.EqualOperator,
-					Left: &ast.MemberExpression{
-						Object:   &ast.Identifier{Name: "r"},
-						Property: &ast.Identifier{Name: "_measurement"},
-					},
-					Right: &ast.StringLiteral{
-						Value: measurementNames[i],
-					},
-				},
-				Right: filterExpr,
-			}
-		}
-		expr = &ast.PipeExpression{
-			Argument: expr,
-			Call: &ast.CallExpression{
-				Callee: &ast.Identifier{
-					Name: "filter",
-				},
-				Arguments: []ast.Expression{
-					&ast.ObjectExpression{
-						Properties: []*ast.Property{
-							{
-								Key: &ast.Identifier{Name: "fn"},
-								Value: &ast.FunctionExpression{
-									Params: []*ast.Property{
-										{
-											Key: &ast.Identifier{Name: "r"},
-										},
-									},
-									Body: filterExpr,
-								},
-							},
-						},
-					},
-				},
-			},
-		}
+	expr, err = filterMeasurements(expr, stmt.Sources)
+	if err != nil {
+		return nil, err
 	}
 
 	// TODO(jsternberg): Add the condition filter for the where clause.
+	if stmt.Condition != nil {
+		if err := t.ignored("SHOW TAG VALUES with WHERE clause"); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create the key values op spec from the
 	var keyColumns []ast.Expression
@@ -390,72 +578,560 @@ func (t *transpilerState) transpileShowTagValues(ctx context.Context, stmt *infl
 	}, nil
 }
 
-func (t *transpilerState) transpileShowDatabases(ctx context.Context, stmt *influxql.ShowDatabasesStatement) (ast.Expression, error) {
-	v1 := t.requireImport("influxdata/influxdb/v1")
+func (t *transpilerState) transpileShowSeries(ctx context.Context, stmt *influxql.ShowSeriesStatement) (ast.Expression, error) {
+	expr, err := t.series(stmt.Database, stmt.Sources, stmt.Condition, "SHOW SERIES")
+	if err != nil {
+		return nil, err
+	}
+
+	if stmt.Limit > 0 || stmt.Offset > 0 {
+		if err := t.ignored("SHOW SERIES with LIMIT or OFFSET"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Flux cannot join the tags of a row into a string because the tag
+	// columns are not known, so every series is put in a single table and
+	// the measurement is renamed to _series. The encoder builds the key
+	// column from the _series column and the tags of each row.
 	return &ast.PipeExpression{
 		Argument: &ast.PipeExpression{
-			Argument: &ast.CallExpression{
-				Callee: &ast.MemberExpression{
-					Object: v1,
-					Property: &ast.Identifier{
-						Name: "databases",
-					},
-				},
-			},
-			Call: &ast.CallExpression{
-				Callee: &ast.Identifier{
-					Name: "rename",
-				},
-				Arguments: []ast.Expression{
-					&ast.ObjectExpression{
-						Properties: []*ast.Property{
-							{
-								Key: &ast.Identifier{
-									Name: "columns",
-								},
-								Value: &ast.ObjectExpression{
-									Properties: []*ast.Property{
-										{
-											Key: &ast.Identifier{Name: "databaseName"},
-											Value: &ast.StringLiteral{
-												Value: "name",
-											},
-										},
-									},
-								},
-							},
+			Argument: expr,
+			Call:     groupByColumns(),
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "rename"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{Name: "columns"},
+						Value: &ast.ObjectExpression{
+							Properties: []*ast.Property{{
+								Key:   &ast.Identifier{Name: "_measurement"},
+								Value: &ast.StringLiteral{Value: "_series"},
+							}},
 						},
-					},
+					}},
 				},
 			},
 		},
+	}, nil
+}
+
+func (t *transpilerState) transpileShowSeriesCardinality(ctx context.Context, stmt *influxql.ShowSeriesCardinalityStatement) (ast.Expression, error) {
+	expr, err := t.series(stmt.Database, stmt.Sources, stmt.Condition, "SHOW SERIES CARDINALITY")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(stmt.Dimensions) > 0 {
+		return nil, errors.New("unimplemented: SHOW SERIES CARDINALITY with GROUP BY")
+	}
+	if stmt.Limit > 0 || stmt.Offset > 0 {
+		if err := t.ignored("SHOW SERIES CARDINALITY with LIMIT or OFFSET"); err != nil {
+			return nil, err
+		}
+	}
+
+	// The series are always counted exactly. Like influxql, the exact count
+	// and a count of the series in specific measurements are reported for
+	// each measurement while the estimate is a single count for the database.
+	var call *ast.CallExpression
+	if stmt.Exact || len(stmt.Sources) > 0 || stmt.Condition != nil {
+		call = groupByColumns("_measurement")
+	} else {
+		call = groupByColumns()
+	}
+	expr = &ast.PipeExpression{
+		Argument: expr,
+		Call:     call,
+	}
+
+	// Each series is a single row without a value so each row is given
+	// a value that can be counted.
+	expr = &ast.PipeExpression{
+		Argument: expr,
 		Call: &ast.CallExpression{
-			Callee: &ast.Identifier{
-				Name: "keep",
-			},
+			Callee: &ast.Identifier{Name: "map"},
 			Arguments: []ast.Expression{
 				&ast.ObjectExpression{
-					Properties: []*ast.Property{
-						{
-							Key: &ast.Identifier{
-								Name: "columns",
-							},
-							Value: &ast.ArrayExpression{
-								Elements: []ast.Expression{
-									&ast.StringLiteral{
-										Value: "name",
-									},
-								},
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{Name: "fn"},
+						Value: &ast.FunctionExpression{
+							Params: []*ast.Property{{
+								Key: &ast.Identifier{Name: "r"},
+							}},
+							Body: &ast.ObjectExpression{
+								With: &ast.Identifier{Name: "r"},
+								Properties: []*ast.Property{{
+									Key:   &ast.Identifier{Name: "_value"},
+									Value: &ast.IntegerLiteral{Value: 1},
+								}},
 							},
 						},
-					},
+					}},
 				},
 			},
 		},
-	}, nil
-}
-
+	}
+	expr = &ast.PipeExpression{
+		Argument: expr,
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "count"},
+		},
+	}
+	return &ast.PipeExpression{
+		Argument: expr,
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "rename"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key: &ast.Identifier{Name: "columns"},
+						Value: &ast.ObjectExpression{
+							Properties: []*ast.Property{{
+								Key:   &ast.Identifier{Name: "_value"},
+								Value: &ast.StringLiteral{Value: "count"},
+							}},
+						},
+					}},
+				},
+			},
+		},
+	}, nil
+}
+
+// series reads one row for each series in the measurements of the sources
+// that matches the condition. The statement is the name of the statement
+// used in error messages.
+func (t *transpilerState) series(database string, sources influxql.Sources, cond influxql.Expr, statement string) (ast.Expression, error) {
+	// Like SHOW TAG VALUES, the database is taken from the statement and the
+	// default retention policy is always used.
+	if database == "" {
+		if t.config.DefaultDatabase == "" {
+			return nil, errDatabaseNameRequired
+		}
+		database = t.config.DefaultDatabase
+	}
+
+	// The series are read from the same default range as SHOW TAG VALUES.
+	expr, err := t.from(&influxql.Measurement{Database: database}, defaultRange)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err = filterMeasurements(expr, sources)
+	if err != nil {
+		return nil, err
+	}
+
+	if cond != nil {
+		if hasTimeRef(cond) {
+			return nil, fmt.Errorf("unimplemented: %s with a time condition", statement)
+		}
+
+		expr, err = t.filterTags(expr, cond)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Drop every column that is not part of the series key so each series
+	// is a single table and keep one row from each of them.
+	return &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: expr,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "drop"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{
+									Name: "columns",
+								},
+								Value: &ast.ArrayExpression{
+									Elements: []ast.Expression{
+										&ast.StringLiteral{Value: "_start"},
+										&ast.StringLiteral{Value: "_stop"},
+										&ast.StringLiteral{Value: "_field"},
+										&ast.StringLiteral{Value: "_time"},
+										&ast.StringLiteral{Value: "_value"},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "limit"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "n",
+							},
+							Value: &ast.IntegerLiteral{
+								Value: 1,
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// groupByColumns creates a call to group the tables by the columns.
+func groupByColumns(columns ...string) *ast.CallExpression {
+	elements := make([]ast.Expression, 0, len(columns))
+	for _, c := range columns {
+		elements = append(elements, &ast.StringLiteral{Value: c})
+	}
+	return &ast.CallExpression{
+		Callee: &ast.Identifier{Name: "group"},
+		Arguments: []ast.Expression{
+			&ast.ObjectExpression{
+				Properties: []*ast.Property{
+					{
+						Key: &ast.Identifier{
+							Name: "columns",
+						},
+						Value: &ast.ArrayExpression{
+							Elements: elements,
+						},
+					},
+					{
+						Key: &ast.Identifier{
+							Name: "mode",
+						},
+						Value: &ast.StringLiteral{
+							Value: "by",
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (t *transpilerState) transpileShowMeasurements(ctx context.Context, stmt *influxql.ShowMeasurementsStatement) (ast.Expression, error) {
+	// Like SHOW TAG VALUES, the database is taken from the statement and the
+	// default retention policy is always used.
+	db := stmt.Database
+	if db == "" {
+		if t.config.DefaultDatabase == "" {
+			return nil, errDatabaseNameRequired
+		}
+		db = t.config.DefaultDatabase
+	}
+
+	// The measurements are read from the same default range as SHOW TAG VALUES.
+	expr, err := t.from(&influxql.Measurement{Database: db}, defaultRange)
+	if err != nil {
+		return nil, err
+	}
+
+	// The WITH MEASUREMENT clause is a single measurement name or regex.
+	if stmt.Source != nil {
+		expr, err = filterMeasurements(expr, influxql.Sources{stmt.Source})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if stmt.Condition != nil {
+		if hasTimeRef(stmt.Condition) {
+			return nil, errors.New("unimplemented: SHOW MEASUREMENTS with a time condition")
+		}
+		expr, err = t.filterTags(expr, stmt.Condition)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(stmt.SortFields) > 0 {
+		if err := t.ignored("SHOW MEASUREMENTS with ORDER BY"); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.Limit > 0 || stmt.Offset > 0 {
+		if err := t.ignored("SHOW MEASUREMENTS with LIMIT or OFFSET"); err != nil {
+			return nil, err
+		}
+	}
+
+	// Keep only the measurement, merge every table together, and then find
+	// the distinct measurement names in sorted order. This is static.
+	return &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: &ast.PipeExpression{
+				Argument: &ast.PipeExpression{
+					Argument: &ast.PipeExpression{
+						Argument: expr,
+						Call: &ast.CallExpression{
+							Callee: &ast.Identifier{Name: "keep"},
+							Arguments: []ast.Expression{
+								&ast.ObjectExpression{
+									Properties: []*ast.Property{
+										{
+											Key: &ast.Identifier{
+												Name: "columns",
+											},
+											Value: &ast.ArrayExpression{
+												Elements: []ast.Expression{
+													&ast.StringLiteral{Value: "_measurement"},
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+					Call: &ast.CallExpression{
+						Callee: &ast.Identifier{Name: "group"},
+					},
+				},
+				Call: &ast.CallExpression{
+					Callee: &ast.Identifier{Name: "distinct"},
+					Arguments: []ast.Expression{
+						&ast.ObjectExpression{
+							Properties: []*ast.Property{
+								{
+									Key: &ast.Identifier{
+										Name: "column",
+									},
+									Value: &ast.StringLiteral{
+										Value: "_measurement",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "sort"},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "rename"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "columns",
+							},
+							Value: &ast.ObjectExpression{
+								Properties: []*ast.Property{
+									{
+										Key: &ast.Identifier{
+											Name: "_value",
+										},
+										Value: &ast.StringLiteral{
+											Value: "name",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+// filterTags filters the input of a SHOW statement with the condition.
+// Every variable in the condition refers to a tag.
+func (t *transpilerState) filterTags(expr ast.Expression, cond influxql.Expr) (ast.Expression, error) {
+	fn, err := t.mapField(cond, &showCursor{expr: expr}, true)
+	if err != nil {
+		return nil, err
+	}
+	if t.config.MergeFilters {
+		if filter := mergeFilter(expr, fn); filter != nil {
+			return filter, nil
+		}
+	}
+	return &ast.PipeExpression{
+		Argument: expr,
+		Call:     filterCall(fn),
+	}, nil
+}
+
+// showCursor is a cursor for the input to a SHOW statement.
+// It only contains tags so every variable reference is a tag
+// except for _name, which refers to the measurement.
+type showCursor struct {
+	expr ast.Expression
+}
+
+func (c *showCursor) Expr() ast.Expression  { return c.expr }
+func (c *showCursor) Keys() []influxql.Expr { return nil }
+func (c *showCursor) Value(expr influxql.Expr) (string, bool) {
+	if ref, ok := expr.(*influxql.VarRef); ok {
+		if ref.Val == "_name" {
+			return "_measurement", true
+		}
+		return ref.Val, true
+	}
+	return "", false
+}
+
+// filterMeasurements filters the input to the measurements in the sources.
+// If there are no sources, the input is returned unchanged.
+func filterMeasurements(expr ast.Expression, sources influxql.Sources) (ast.Expression, error) {
+	// Look through the list of sources and compare the measurement to each of them.
+	exprs := make([]ast.Expression, 0, len(sources))
+	for _, source := range sources {
+		mm, ok := source.(*influxql.Measurement)
+		if !ok {
+			return nil, fmt.Errorf("unimplemented: source type %T", source)
+		}
+
+		if mm.Regex != nil {
+			exprs = append(exprs, &ast.BinaryExpression{
+				Operator: ast.RegexpMatchOperator,
+				Left: &ast.MemberExpression{
+					Object:   &ast.Identifier{Name: "r"},
+					Property: &ast.Identifier{Name: "_measurement"},
+				},
+				Right: &ast.RegexpLiteral{
+					Value: mm.Regex.Val,
+				},
+			})
+			continue
+		}
+		exprs = append(exprs, &ast.BinaryExpression{
+			Operator: ast.EqualOperator,
+			Left: &ast.MemberExpression{
+				Object:   &ast.Identifier{Name: "r"},
+				Property: &ast.Identifier{Name: "_measurement"},
+			},
+			Right: &ast.StringLiteral{
+				Value: mm.Name,
+			},
+		})
+	}
+
+	if len(exprs) > 0 {
+		filterExpr := exprs[len(exprs)-1]
+		for i := len(exprs) - 2; i >= 0; i-- {
+			filterExpr = &ast.LogicalExpression{
+				Operator: ast.OrOperator,
+				Left:     exprs[i],
+				Right:    filterExpr,
+			}
+		}
+		expr = &ast.PipeExpression{
+			Argument: expr,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "filter",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{Name: "fn"},
+								Value: &ast.FunctionExpression{
+									Params: []*ast.Property{
+										{
+											Key: &ast.Identifier{Name: "r"},
+										},
+									},
+									Body: filterExpr,
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	return expr, nil
+}
+
+func (t *transpilerState) transpileShowDatabases(ctx context.Context, stmt *influxql.ShowDatabasesStatement) (ast.Expression, error) {
+	v1 := t.requireImport("influxdata/influxdb/v1")
+	return &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: &ast.CallExpression{
+				Callee: &ast.MemberExpression{
+					Object: v1,
+					Property: &ast.Identifier{
+						Name: "databases",
+					},
+				},
+			},
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "rename",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{
+									Name: "columns",
+								},
+								Value: &ast.ObjectExpression{
+									Properties: []*ast.Property{
+										{
+											Key: &ast.Identifier{Name: "databaseName"},
+											Value: &ast.StringLiteral{
+												Value: "name",
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "keep",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "columns",
+							},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{
+									&ast.StringLiteral{
+										Value: "name",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
 func (t *transpilerState) transpileShowRetentionPolicies(ctx context.Context, stmt *influxql.ShowRetentionPoliciesStatement) (ast.Expression, error) {
+	db := stmt.Database
+	if db == "" {
+		if t.config.DefaultDatabase == "" {
+			return nil, errDatabaseNameRequired
+		}
+		db = t.config.DefaultDatabase
+	}
+
 	v1 := t.requireImport("influxdata/influxdb/v1")
 	return &ast.PipeExpression{
 		Argument: &ast.PipeExpression{
@@ -500,7 +1176,7 @@ func (t *transpilerState) transpileShowRetentionPolicies(ctx context.Context, st
 														},
 													},
 													Right: &ast.StringLiteral{
-														Value: stmt.Database,
+														Value: db,
 													},
 												},
 											},
@@ -537,30 +1213,402 @@ func (t *transpilerState) transpileShowRetentionPolicies(ctx context.Context, st
 						},
 					},
 				},
-				Call: &ast.CallExpression{
-					Callee: &ast.Identifier{
-						Name: "set",
-					},
-					Arguments: []ast.Expression{
-						&ast.ObjectExpression{
-							Properties: []*ast.Property{
-								{
-									Key: &ast.Identifier{Name: "key"},
-									Value: &ast.StringLiteral{
-										Value: "shardGroupDuration",
+				Call: &ast.CallExpression{
+					Callee: &ast.Identifier{
+						Name: "set",
+					},
+					Arguments: []ast.Expression{
+						&ast.ObjectExpression{
+							Properties: []*ast.Property{
+								{
+									Key: &ast.Identifier{Name: "key"},
+									Value: &ast.StringLiteral{
+										Value: "shardGroupDuration",
+									},
+								},
+								{
+									Key: &ast.Identifier{Name: "value"},
+									Value: &ast.StringLiteral{
+										Value: "0",
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "set",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							{
+								Key: &ast.Identifier{Name: "key"},
+								Value: &ast.StringLiteral{
+									Value: "replicaN",
+								},
+							},
+							{
+								Key: &ast.Identifier{Name: "value"},
+								Value: &ast.StringLiteral{
+									Value: "2",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "keep",
+			},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{
+								Name: "columns",
+							},
+							Value: &ast.ArrayExpression{
+								Elements: []ast.Expression{
+									&ast.StringLiteral{Value: "name"},
+									&ast.StringLiteral{Value: "duration"},
+									&ast.StringLiteral{Value: "shardGroupDuration"},
+									&ast.StringLiteral{Value: "replicaN"},
+									&ast.StringLiteral{Value: "default"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (t *transpilerState) transpileSelect(ctx context.Context, stmt *influxql.SelectStatement) (cursor, error) {
+	// Clone the select statement and omit the time from the list of column names.
+	t.stmt = stmt.Clone()
+	t.stmt.OmitTime = true
+	if !t.config.ShareSources || t.sources == nil {
+		t.sources = make(map[string]*ast.Identifier)
+	}
+
+	if err := checkDistinct(t.stmt.Fields); err != nil {
+		return nil, err
+	}
+	if err := checkLiteralFields(t.stmt.Fields); err != nil {
+		return nil, err
+	}
+	// The distinct keyword is the same as calling distinct().
+	for _, f := range t.stmt.Fields {
+		if expr, ok := f.Expr.(*influxql.Distinct); ok {
+			f.Expr = expr.NewCall()
+		}
+	}
+	if err := checkMixedFields(t.stmt.Fields); err != nil {
+		return nil, err
+	}
+	if err := t.checkIgnoredFeatures(); err != nil {
+		return nil, err
+	}
+	t.checkDeprecatedFeatures()
+	if err := t.expandFields(ctx); err != nil {
+		return nil, err
+	}
+	if err := t.resolveTypes(ctx); err != nil {
+		return nil, err
+	}
+	for _, source := range t.stmt.Sources {
+		if sq, ok := source.(*influxql.SubQuery); ok {
+			if err := t.transpileSubquery(ctx, sq); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	groups, err := identifyGroups(t.stmt, t.config.FunctionRegistry)
+	if err != nil {
+		return nil, err
+	} else if len(groups) == 0 {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInvalid,
+			Msg:  "unable to transpile: at least one non-time field must be queried",
+		}
+	}
+
+	cursors := make([]cursor, 0, len(groups))
+	for _, gr := range groups {
+		cur, err := gr.createCursor(t)
+		if err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, cur)
+	}
+
+	// Join the cursors together on the measurement name and the group keys.
+	cur, err := Join(t, cursors, t.joinColumns())
+	if err != nil {
+		return nil, err
+	}
+
+	// Map each of the fields into another cursor. This evaluates any lingering expressions.
+	cur, err = t.mapFields(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	// The series limit is applied after the fields are joined
+	// so each series is only counted once.
+	if t.stmt.SLimit > 0 {
+		cur, err = t.limitSeries(cur, t.stmt.SLimit, t.stmt.SOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The points are read in ascending time order so they only need
+	// to be sorted when they are ordered by descending time or when
+	// the series limit has joined them out of order.
+	if !t.stmt.TimeAscending() || t.stmt.SLimit > 0 {
+		cur = sortByTime(cur, !t.stmt.TimeAscending())
+	}
+	if t.stmt.Limit > 0 {
+		cur = limitPoints(cur, t.stmt.Limit, t.stmt.Offset)
+	}
+
+	// Write the results to the target measurement when there is an INTO clause.
+	if t.stmt.Target != nil {
+		return t.into(cur)
+	}
+	return cur, nil
+}
+
+// transpileSubquery transpiles the statement of the subquery and assigns
+// the results to a variable so they can be read as a source by the query.
+func (t *transpilerState) transpileSubquery(ctx context.Context, sq *influxql.SubQuery) error {
+	if len(sq.Statement.SortFields) > 0 && sq.Statement.TimeAscending() != t.stmt.TimeAscending() {
+		return errors.New("subqueries must be ordered in the same direction as the query itself")
+	}
+
+	// The subquery only reads the time range of the query it is in. The
+	// condition is added to a copy so the statement is not modified.
+	valuer := influxql.NowValuer{Now: t.config.Now}
+	cond, err := timeCondition(t.stmt.Condition, &valuer)
+	if err != nil {
+		return err
+	}
+	subquery := sq.Statement
+	if cond != nil {
+		subquery = subquery.Clone()
+		if subquery.Condition != nil {
+			cond = &influxql.BinaryExpr{
+				Op:  influxql.AND,
+				LHS: &influxql.ParenExpr{Expr: subquery.Condition},
+				RHS: cond,
+			}
+		}
+		subquery.Condition = cond
+	}
+
+	// The subquery replaces the statement and sources that are being
+	// transpiled so they are restored once it is done.
+	stmt, sources := t.stmt, t.sources
+	defer func() {
+		t.stmt, t.sources = stmt, sources
+	}()
+
+	cur, err := t.transpileSelect(ctx, subquery)
+	if err != nil {
+		return err
+	}
+	t.subqueries[sq] = t.assignment(cur.Expr())
+	return nil
+}
+
+// sortByTime sorts the points in each table by time.
+func sortByTime(in cursor, desc bool) cursor {
+	properties := []*ast.Property{
+		{
+			Key: &ast.Identifier{Name: "columns"},
+			Value: &ast.ArrayExpression{
+				Elements: []ast.Expression{
+					&ast.StringLiteral{Value: "_time"},
+				},
+			},
+		},
+	}
+	if desc {
+		properties = append(properties, &ast.Property{
+			Key:   &ast.Identifier{Name: "desc"},
+			Value: &ast.BooleanLiteral{Value: true},
+		})
+	}
+	return &pipeCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "sort",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: properties,
+					},
+				},
+			},
+		},
+		cursor: in,
+	}
+}
+
+// limitPoints limits the number of points in each table to n after skipping
+// the first offset points. Each table is a series so this is the same as
+// the LIMIT and OFFSET of a select statement.
+func limitPoints(in cursor, n, offset int) cursor {
+	properties := []*ast.Property{
+		{
+			Key:   &ast.Identifier{Name: "n"},
+			Value: &ast.IntegerLiteral{Value: int64(n)},
+		},
+	}
+	if offset > 0 {
+		properties = append(properties, &ast.Property{
+			Key:   &ast.Identifier{Name: "offset"},
+			Value: &ast.IntegerLiteral{Value: int64(offset)},
+		})
+	}
+	return &pipeCursor{
+		expr: &ast.PipeExpression{
+			Argument: in.Expr(),
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "limit",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: properties,
+					},
+				},
+			},
+		},
+		cursor: in,
+	}
+}
+
+// limitSeries limits the number of series to n after skipping the first
+// offset series. Flux cannot limit the number of tables so the key of each
+// series is read into a single table where the keys are sorted and limited.
+// The tables are then joined with the remaining keys, which drops every
+// other series. The join does not keep the order of the points.
+func (t *transpilerState) limitSeries(in cursor, n, offset int) (cursor, error) {
+	// The keys are sorted and joined on the tag columns. With GROUP BY *,
+	// the names of the tags are not known when the query is transpiled
+	// and both sort and join need them, so it cannot be limited this way.
+	for _, d := range t.stmt.Dimensions {
+		if _, ok := d.Expr.(*influxql.Wildcard); ok {
+			return nil, errors.New("unimplemented: SLIMIT with GROUP BY *")
+		}
+	}
+	// The series are identified by the measurement and the tags
+	// that are grouped by. The time is not part of the key.
+	columns := t.joinColumns()[1:]
+	elements := make([]ast.Expression, 0, len(columns))
+	for _, c := range columns {
+		elements = append(elements, &ast.StringLiteral{Value: c})
+	}
+
+	tables := t.assignment(in.Expr())
+	var keys ast.Expression = &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: &ast.Identifier{Name: tables.Name},
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "keep"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key:   &ast.Identifier{Name: "columns"},
+							Value: &ast.ArrayExpression{Elements: elements},
+						}},
+					},
+				},
+			},
+		},
+		Call: groupByColumns(columns...),
+	}
+	// Only the first row of each series is needed for its key.
+	keys = limitPoints(&pipeCursor{expr: keys}, 1, 0).Expr()
+	keys = &ast.PipeExpression{
+		Argument: &ast.PipeExpression{
+			Argument: keys,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "group"},
+			},
+		},
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "sort"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{{
+						Key:   &ast.Identifier{Name: "columns"},
+						Value: &ast.ArrayExpression{Elements: elements},
+					}},
+				},
+			},
+		},
+	}
+	keys = limitPoints(&pipeCursor{expr: keys}, n, offset).Expr()
+
+	return &pipeCursor{
+		expr: &ast.CallExpression{
+			Callee: &ast.Identifier{Name: "join"},
+			Arguments: []ast.Expression{
+				&ast.ObjectExpression{
+					Properties: []*ast.Property{
+						{
+							Key: &ast.Identifier{Name: "tables"},
+							Value: &ast.ObjectExpression{
+								Properties: []*ast.Property{
+									{
+										Key:   &ast.Identifier{Name: tables.Name},
+										Value: &ast.Identifier{Name: tables.Name},
 									},
-								},
-								{
-									Key: &ast.Identifier{Name: "value"},
-									Value: &ast.StringLiteral{
-										Value: "0",
+									{
+										Key:   &ast.Identifier{Name: "keys"},
+										Value: t.assignment(keys),
 									},
 								},
 							},
 						},
+						{
+							Key:   &ast.Identifier{Name: "on"},
+							Value: &ast.ArrayExpression{Elements: elements},
+						},
 					},
 				},
 			},
+		},
+		cursor: in,
+	}, nil
+}
+
+// into writes the results of the select statement to the target measurement.
+func (t *transpilerState) into(in cursor) (cursor, error) {
+	target := t.stmt.Target.Measurement
+	bucket, err := t.bucket(target.Database, target.RetentionPolicy)
+	if err != nil {
+		return nil, err
+	}
+
+	// The measurement name is retained from the source when
+	// the target is :MEASUREMENT.
+	expr := in.Expr()
+	if target.Name != "" {
+		expr = &ast.PipeExpression{
+			Argument: expr,
 			Call: &ast.CallExpression{
 				Callee: &ast.Identifier{
 					Name: "set",
@@ -569,40 +1617,70 @@ func (t *transpilerState) transpileShowRetentionPolicies(ctx context.Context, st
 					&ast.ObjectExpression{
 						Properties: []*ast.Property{
 							{
-								Key: &ast.Identifier{Name: "key"},
-								Value: &ast.StringLiteral{
-									Value: "replicaN",
-								},
+								Key:   &ast.Identifier{Name: "key"},
+								Value: &ast.StringLiteral{Value: "_measurement"},
 							},
 							{
-								Key: &ast.Identifier{Name: "value"},
-								Value: &ast.StringLiteral{
-									Value: "2",
-								},
+								Key:   &ast.Identifier{Name: "value"},
+								Value: &ast.StringLiteral{Value: target.Name},
 							},
 						},
 					},
 				},
 			},
-		},
-		Call: &ast.CallExpression{
-			Callee: &ast.Identifier{
-				Name: "keep",
+		}
+	}
+
+	// The tags that are written are the ones in the GROUP BY clause.
+	tags := []ast.Expression{}
+	for _, d := range t.stmt.Dimensions {
+		if ref, ok := d.Expr.(*influxql.VarRef); ok {
+			tags = append(tags, &ast.StringLiteral{Value: ref.Val})
+		}
+	}
+
+	// Each of the columns is written as a field with the same name.
+	columns := t.stmt.ColumnNames()
+	fields := make([]*ast.Property, 0, len(columns))
+	for i, f := range t.stmt.Fields {
+		if ref, ok := f.Expr.(*influxql.VarRef); ok && ref.Val == "time" {
+			continue
+		}
+		fields = append(fields, &ast.Property{
+			Key: &ast.StringLiteral{Value: columns[i]},
+			Value: &ast.MemberExpression{
+				Object:   &ast.Identifier{Name: "r"},
+				Property: &ast.StringLiteral{Value: columns[i]},
 			},
-			Arguments: []ast.Expression{
-				&ast.ObjectExpression{
-					Properties: []*ast.Property{
-						{
-							Key: &ast.Identifier{
-								Name: "columns",
+		})
+	}
+
+	return &pipeCursor{
+		expr: &ast.PipeExpression{
+			Argument: expr,
+			Call: &ast.CallExpression{
+				Callee: &ast.Identifier{
+					Name: "to",
+				},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{
+							bucket,
+							{
+								Key: &ast.Identifier{Name: "tagColumns"},
+								Value: &ast.ArrayExpression{
+									Elements: tags,
+								},
 							},
-							Value: &ast.ArrayExpression{
-								Elements: []ast.Expression{
-									&ast.StringLiteral{Value: "name"},
-									&ast.StringLiteral{Value: "duration"},
-									&ast.StringLiteral{Value: "shardGroupDuration"},
-									&ast.StringLiteral{Value: "replicaN"},
-									&ast.StringLiteral{Value: "default"},
+							{
+								Key: &ast.Identifier{Name: "fieldFn"},
+								Value: &ast.FunctionExpression{
+									Params: []*ast.Property{{
+										Key: &ast.Identifier{Name: "r"},
+									}},
+									Body: &ast.ObjectExpression{
+										Properties: fields,
+									},
 								},
 							},
 						},
@@ -610,162 +1688,351 @@ func (t *transpilerState) transpileShowRetentionPolicies(ctx context.Context, st
 				},
 			},
 		},
+		cursor: in,
 	}, nil
 }
 
-func (t *transpilerState) transpileSelect(ctx context.Context, stmt *influxql.SelectStatement) (cursor, error) {
-	// Clone the select statement and omit the time from the list of column names.
-	t.stmt = stmt.Clone()
-	t.stmt.OmitTime = true
-
-	groups, err := identifyGroups(t.stmt)
-	if err != nil {
-		return nil, err
-	} else if len(groups) == 0 {
-		return nil, &influxdb.Error{
-			Code: influxdb.EInvalid,
-			Msg:  "unable to transpile: at least one non-time field must be queried",
+// checkIgnoredFeatures reports the features used by the select statement that
+// are not implemented and would be ignored by the transpiler.
+func (t *transpilerState) checkIgnoredFeatures() error {
+	// The limit function in Flux requires the number of points so an
+	// offset cannot be used on its own. The same is true of the series.
+	if t.stmt.Limit == 0 && t.stmt.Offset > 0 {
+		if err := t.ignored("OFFSET without LIMIT"); err != nil {
+			return err
+		}
+	}
+	if t.stmt.SLimit == 0 && t.stmt.SOffset > 0 {
+		if err := t.ignored("SOFFSET without SLIMIT"); err != nil {
+			return err
 		}
 	}
+	if t.stmt.Location != nil {
+		if err := t.ignored("tz() function"); err != nil {
+			return err
+		}
+	}
+	if t.stmt.Fill == influxql.LinearFill {
+		if err := t.ignored(fmt.Sprintf("fill(%s)", fillName(t.stmt))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	cursors := make([]cursor, 0, len(groups))
-	for _, gr := range groups {
-		cur, err := gr.createCursor(t)
-		if err != nil {
-			return nil, err
+// checkDeprecatedFeatures reports the features used by the select statement
+// that are deprecated. They are still transpiled.
+func (t *transpilerState) checkDeprecatedFeatures() {
+	for _, d := range t.stmt.Dimensions {
+		call, ok := d.Expr.(*influxql.Call)
+		if !ok || call.Name != "time" || len(call.Args) != 2 {
+			continue
+		}
+		if offset, ok := call.Args[1].(*influxql.Call); ok && offset.Name == "now" {
+			t.warn("GROUP BY time() with a now() offset is deprecated")
 		}
-		cursors = append(cursors, cur)
 	}
+}
 
-	// Join the cursors together on the measurement name.
-	// TODO(jsternberg): This needs to join on all remaining group keys.
-	cur := Join(t, cursors, []string{"_time", "_measurement"})
+// ignored is called when the transpiler ignores a feature that has not been
+// implemented. In strict mode, this returns an error instead of allowing the
+// transpiled query to silently be incomplete.
+func (t *transpilerState) ignored(feature string) error {
+	if t.config.StrictMode {
+		return fmt.Errorf("unimplemented: %s", feature)
+	}
+	t.warn(fmt.Sprintf("%s is not implemented and was ignored", feature))
+	return nil
+}
 
-	// Map each of the fields into another cursor. This evaluates any lingering expressions.
-	cur, err = t.mapFields(cur)
-	if err != nil {
-		return nil, err
+// warn records a warning about the query and reports it to the OnWarning callback.
+func (t *transpilerState) warn(msg string) {
+	w := Warning{Message: msg}
+	t.warnings = append(t.warnings, w)
+	if t.config.OnWarning != nil {
+		t.config.OnWarning(w)
+	}
+}
+
+// fillName returns the name of the fill option as it is written in influxql.
+func fillName(stmt *influxql.SelectStatement) string {
+	switch stmt.Fill {
+	case influxql.NullFill:
+		return "null"
+	case influxql.NoFill:
+		return "none"
+	case influxql.PreviousFill:
+		return "previous"
+	case influxql.LinearFill:
+		return "linear"
+	default:
+		return fmt.Sprint(stmt.FillValue)
 	}
-	return cur, nil
 }
 
 func (t *transpilerState) mapType(ref *influxql.VarRef) influxql.DataType {
+	// A reference that was cast to a field type is read as a field.
+	switch ref.Type {
+	case influxql.Float, influxql.Integer, influxql.Unsigned, influxql.String, influxql.Boolean, influxql.AnyField:
+		return ref.Type
+	}
 	// TODO(jsternberg): Actually evaluate the type against the schema.
 	return influxql.Tag
 }
 
-func (t *transpilerState) from(m *influxql.Measurement) (ast.Expression, error) {
-	var args []ast.Expression
-	// Use the bucket inteasd of dbrp mapping if it exists.
-	if t.config.Bucket != "" {
-		args = []ast.Expression{
-			&ast.ObjectExpression{
-				Properties: []*ast.Property{
-					{
-						Key: &ast.Identifier{
-							Name: "bucket",
-						},
-						Value: &ast.StringLiteral{
-							Value: t.config.Bucket,
-						},
-					},
-				},
-			},
-		}
-	} else {
-		if t.dbrpMappingSvc == nil {
-			return nil, &influxdb.Error{
-				Code: influxdb.EInternal,
-				Msg:  "unable to transpile: db and rp mappings need to be created by some way",
-			}
-		}
-		db, rp := m.Database, m.RetentionPolicy
-		if db == "" {
-			if t.config.DefaultDatabase == "" {
-				return nil, &influxdb.Error{
-					Code: influxdb.EInvalid,
-					Msg:  "unable to transpile: database is required",
-				}
-			}
-			db = t.config.DefaultDatabase
+// from reads the tables for the measurement from its bucket and pipes them
+// into the expression created by fn, which must start with a range. When the
+// BucketFederationFn returns more than one bucket, fn is used for each bucket
+// so every from is bounded by a range and the results are merged with a union.
+func (t *transpilerState) from(m *influxql.Measurement, fn func(from ast.Expression) ast.Expression) (ast.Expression, error) {
+	var buckets []*ast.Property
+	if t.config.BucketFederationFn != nil && t.config.Bucket == "" {
+		var err error
+		if buckets, err = t.federatedBuckets(m); err != nil {
+			return nil, err
 		}
-		if rp == "" {
-			if t.config.DefaultRetentionPolicy != "" {
-				rp = t.config.DefaultRetentionPolicy
-			}
+	}
+	if len(buckets) == 0 {
+		bucket, err := t.bucket(m.Database, m.RetentionPolicy)
+		if err != nil {
+			return nil, err
 		}
+		buckets = []*ast.Property{bucket}
+	}
 
-		var filter influxdb.DBRPMappingFilterV2
-		if db != "" {
-			filter.Database = &db
-		}
-		if rp != "" {
-			filter.RetentionPolicy = &rp
-		}
-		defaultRP := rp == ""
-		filter.Default = &defaultRP
-		mappings, _, err := t.dbrpMappingSvc.FindMany(context.TODO(), filter)
-		if err != nil || len(mappings) == 0 {
-			if !t.config.FallbackToDBRP {
-				return nil, err
-			}
-			// use `db/rp` naming convention
-			args = []ast.Expression{
+	tables := make([]ast.Expression, 0, len(buckets))
+	for _, bucket := range buckets {
+		tables = append(tables, fn(&ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "from",
+			},
+			Arguments: []ast.Expression{
 				&ast.ObjectExpression{
-					Properties: []*ast.Property{
-						{
-							Key: &ast.Identifier{
-								Name: "bucket",
-							},
-							Value: &ast.StringLiteral{
-								Value: fmt.Sprintf("%s/%s", db, rp),
-							},
-						},
-					},
+					Properties: []*ast.Property{bucket},
 				},
-			}
-		} else {
-			// use mapping bucket id
-			args = []ast.Expression{
+			},
+		}))
+	}
+
+	if len(tables) == 1 {
+		return tables[0], nil
+	}
+	for i, expr := range tables {
+		tables[i] = t.assignment(expr)
+	}
+	return &ast.CallExpression{
+		Callee: &ast.Identifier{
+			Name: "union",
+		},
+		Arguments: []ast.Expression{
+			&ast.ObjectExpression{
+				Properties: []*ast.Property{{
+					Key: &ast.Identifier{Name: "tables"},
+					Value: &ast.ArrayExpression{
+						Elements: tables,
+					},
+				}},
+			},
+		},
+	}, nil
+}
+
+// federatedBuckets returns the properties for every bucket returned by the
+// BucketFederationFn for the database and retention policy. The database
+// and retention policy have the same defaults as they do in bucket.
+func (t *transpilerState) federatedBuckets(m *influxql.Measurement) ([]*ast.Property, error) {
+	db, rp, err := t.dbrp(m)
+	if err != nil {
+		return nil, err
+	}
+
+	names := t.config.BucketFederationFn(db, rp)
+	buckets := make([]*ast.Property, 0, len(names))
+	for _, name := range names {
+		buckets = append(buckets, &ast.Property{
+			Key:   &ast.Identifier{Name: "bucket"},
+			Value: &ast.StringLiteral{Value: name},
+		})
+	}
+	return buckets, nil
+}
+
+// dbrp returns the database and retention policy of the measurement that
+// are passed to the functions in the config. They default to the ones in
+// the config and the retention policy is autogen if neither has one.
+func (t *transpilerState) dbrp(m *influxql.Measurement) (db, rp string, err error) {
+	db, rp = m.Database, m.RetentionPolicy
+	if db == "" {
+		if t.config.DefaultDatabase == "" {
+			return "", "", errNoDatabaseSpecified
+		}
+		db = t.config.DefaultDatabase
+	}
+	if rp == "" {
+		rp = t.config.DefaultRetentionPolicy
+	}
+	if rp == "" {
+		rp = "autogen"
+	}
+	return db, rp, nil
+}
+
+// defaultRange reads the last hour of data. It is used by the statements
+// that do not read the range from a time condition.
+func defaultRange(from ast.Expression) ast.Expression {
+	return &ast.PipeExpression{
+		Argument: from,
+		Call: &ast.CallExpression{
+			Callee: &ast.Identifier{
+				Name: "range",
+			},
+			Arguments: []ast.Expression{
 				&ast.ObjectExpression{
 					Properties: []*ast.Property{
 						{
 							Key: &ast.Identifier{
-								Name: "bucketID",
+								Name: "start",
 							},
-							Value: &ast.StringLiteral{
-								Value: mappings[0].BucketID.String(),
+							Value: &ast.DurationLiteral{
+								Values: []ast.Duration{{
+									Magnitude: -1,
+									Unit:      "h",
+								}},
 							},
 						},
 					},
 				},
+			},
+		},
+	}
+}
+
+// bucket returns the property that identifies the bucket for the
+// database and retention policy.
+func (t *transpilerState) bucket(db, rp string) (*ast.Property, error) {
+	// Use the bucket inteasd of dbrp mapping if it exists.
+	if t.config.Bucket != "" {
+		return &ast.Property{
+			Key: &ast.Identifier{
+				Name: "bucket",
+			},
+			Value: &ast.StringLiteral{
+				Value: t.config.Bucket,
+			},
+		}, nil
+	}
+
+	if t.dbrpMappingSvc == nil {
+		return nil, &influxdb.Error{
+			Code: influxdb.EInternal,
+			Msg:  "unable to transpile: db and rp mappings need to be created by some way",
+		}
+	}
+	if db == "" {
+		if t.config.DefaultDatabase == "" {
+			return nil, errNoDatabaseSpecified
+		}
+		db = t.config.DefaultDatabase
+	}
+	if rp == "" {
+		if t.config.DefaultRetentionPolicy != "" {
+			rp = t.config.DefaultRetentionPolicy
+		}
+	}
+
+	var filter influxdb.DBRPMappingFilterV2
+	if db != "" {
+		filter.Database = &db
+	}
+	if rp != "" {
+		filter.RetentionPolicy = &rp
+	}
+	defaultRP := rp == ""
+	filter.Default = &defaultRP
+	mappings, _, err := t.dbrpMappingSvc.FindMany(context.TODO(), filter)
+	if err != nil || len(mappings) == 0 {
+		if !t.config.FallbackToDBRP {
+			if err == nil {
+				err = &influxdb.Error{
+					Code: influxdb.ENotFound,
+					Msg:  fmt.Sprintf("unable to transpile: no bucket is mapped to database %q", db),
+				}
 			}
+			return nil, err
+		}
+		if rp == "" {
+			rp = "autogen"
 		}
+		// use `db/rp` naming convention
+		return &ast.Property{
+			Key: &ast.Identifier{
+				Name: "bucket",
+			},
+			Value: &ast.StringLiteral{
+				Value: fmt.Sprintf("%s/%s", db, rp),
+			},
+		}, nil
 	}
 
-	return &ast.CallExpression{
-		Callee: &ast.Identifier{
-			Name: "from",
+	// use mapping bucket id
+	return &ast.Property{
+		Key: &ast.Identifier{
+			Name: "bucketID",
+		},
+		Value: &ast.StringLiteral{
+			Value: mappings[0].BucketID.String(),
 		},
-		Arguments: args,
 	}, nil
 }
 
+// assignment assigns the expression to a new variable and returns the
+// identifier for it. The names from the IdentifierFn are tried first, but
+// it is called at most once more than there are variables. If none of those
+// names are free, it repeats names and t0, t1, and so on are used instead.
 func (t *transpilerState) assignment(expr ast.Expression) *ast.Identifier {
+	if t.config.IdentifierFn != nil {
+		for i := 0; i <= len(t.assignments); i++ {
+			if key := t.config.IdentifierFn(i); !t.assigned(key) {
+				return t.assign(key, expr)
+			}
+		}
+	}
 	for i := 0; ; i++ {
-		key := fmt.Sprintf("t%d", i)
-		if _, ok := t.assignments[key]; !ok {
-			ident := &ast.Identifier{Name: key}
-			t.assignments[key] = expr
-			t.file.Body = append(t.file.Body, &ast.VariableAssignment{
-				ID:   ident,
-				Init: expr,
-			})
-			return ident
+		if key := fmt.Sprintf("t%d", i); !t.assigned(key) {
+			return t.assign(key, expr)
 		}
 	}
 }
 
+// assigned returns true if the name is already used by a variable.
+func (t *transpilerState) assigned(key string) bool {
+	_, ok := t.assignments[key]
+	return ok
+}
+
+// assign assigns the expression to the variable with the name.
+func (t *transpilerState) assign(key string, expr ast.Expression) *ast.Identifier {
+	ident := &ast.Identifier{Name: key}
+	t.assignments[key] = expr
+	t.file.Body = append(t.file.Body, &ast.VariableAssignment{
+		ID:   ident,
+		Init: expr,
+	})
+	return ident
+}
+
+// sharedSource assigns the source expression to a variable so it can be read
+// by multiple cursors. Identical source expressions share the same variable.
+func (t *transpilerState) sharedSource(expr ast.Expression) ast.Expression {
+	key := ast.Format(expr)
+	ident, ok := t.sources[key]
+	if !ok {
+		ident = t.assignment(expr)
+		t.sources[key] = ident
+	}
+	return &ast.Identifier{Name: ident.Name}
+}
+
 // requireImport will ensure the import is included in the file and return
 // the variable used to access the package.
 func (t *transpilerState) requireImport(pkgpath string) *ast.Identifier {
@@ -2,14 +2,21 @@ package influxql_test
 
 import (
 	"context"
+	"fmt"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/andreyvit/diff"
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/flux/ast"
+	"github.com/influxdata/flux/parser"
 	platform "github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/mock"
 	"github.com/influxdata/influxdb/v2/query/influxql"
 	"github.com/influxdata/influxdb/v2/query/influxql/spectests"
 	platformtesting "github.com/influxdata/influxdb/v2/testing"
+	influxqllib "github.com/influxdata/influxql"
 	"github.com/pkg/errors"
 )
 
@@ -37,119 +44,132 @@ func TestTranspiler(t *testing.T) {
 	}
 }
 
+func TestTranspiler_JSONRoundtrip(t *testing.T) {
+	for _, fixture := range spectests.All() {
+		fixture.RunJSONRoundtrip(t)
+	}
+}
+
 // TestTranspiler_Compile contains the compilation tests from influxdb. It only verifies if
 // each of these queries either succeeds or it fails with the proper message for compatibility.
+// The queries that use a feature the transpiler does not implement list the error it returns.
 func TestTranspiler_Compile(t *testing.T) {
 	for _, tt := range []struct {
-		s   string
-		err string // if empty, no error is expected
+		s             string
+		err           string // if empty, no error is expected
+		unimplemented string // if set, the error returned instead of err
 	}{
 		{s: `SELECT time, value FROM cpu`},
 		{s: `SELECT value FROM cpu`},
 		{s: `SELECT value, host FROM cpu`},
-		{s: `SELECT * FROM cpu`},
-		{s: `SELECT time, * FROM cpu`},
-		{s: `SELECT value, * FROM cpu`},
+		{s: `SELECT * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT time, * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT value, * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
 		{s: `SELECT max(value) FROM cpu`},
 		{s: `SELECT max(value), host FROM cpu`},
-		{s: `SELECT max(value), * FROM cpu`},
-		{s: `SELECT max(*) FROM cpu`},
-		{s: `SELECT max(/val/) FROM cpu`},
+		{s: `SELECT max(value), * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT max(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT max(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT min(value) FROM cpu`},
 		{s: `SELECT min(value), host FROM cpu`},
-		{s: `SELECT min(value), * FROM cpu`},
-		{s: `SELECT min(*) FROM cpu`},
-		{s: `SELECT min(/val/) FROM cpu`},
+		{s: `SELECT min(value), * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT min(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT min(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT first(value) FROM cpu`},
 		{s: `SELECT first(value), host FROM cpu`},
-		{s: `SELECT first(value), * FROM cpu`},
-		{s: `SELECT first(*) FROM cpu`},
-		{s: `SELECT first(/val/) FROM cpu`},
+		{s: `SELECT first(value), * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT first(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT first(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT last(value) FROM cpu`},
 		{s: `SELECT last(value), host FROM cpu`},
-		{s: `SELECT last(value), * FROM cpu`},
-		{s: `SELECT last(*) FROM cpu`},
-		{s: `SELECT last(/val/) FROM cpu`},
+		{s: `SELECT last(value), * FROM cpu`, unimplemented: `unimplemented: field wildcard`},
+		{s: `SELECT last(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT last(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT count(value) FROM cpu`},
-		{s: `SELECT count(distinct(value)) FROM cpu`},
-		{s: `SELECT count(distinct value) FROM cpu`},
+		{s: `SELECT count(distinct(value)) FROM cpu`, unimplemented: `unimplemented: count(distinct)`},
+		{s: `SELECT count(distinct value) FROM cpu`, unimplemented: `unimplemented: count(distinct)`},
 		{s: `SELECT count(*) FROM cpu`},
-		{s: `SELECT count(/val/) FROM cpu`},
+		{s: `SELECT count(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT mean(value) FROM cpu`},
-		{s: `SELECT mean(*) FROM cpu`},
-		{s: `SELECT mean(/val/) FROM cpu`},
+		{s: `SELECT mean(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT mean(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT min(value), max(value) FROM cpu`},
-		{s: `SELECT min(*), max(*) FROM cpu`},
-		{s: `SELECT min(/val/), max(/val/) FROM cpu`},
+		{s: `SELECT min(*), max(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT min(/val/), max(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT first(value), last(value) FROM cpu`},
-		{s: `SELECT first(*), last(*) FROM cpu`},
-		{s: `SELECT first(/val/), last(/val/) FROM cpu`},
+		{s: `SELECT first(*), last(*) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT first(/val/), last(/val/) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
 		{s: `SELECT count(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m)`},
 		{s: `SELECT distinct value FROM cpu`},
 		{s: `SELECT distinct(value) FROM cpu`},
 		{s: `SELECT value / total FROM cpu`},
 		{s: `SELECT min(value) / total FROM cpu`},
 		{s: `SELECT max(value) / total FROM cpu`},
-		{s: `SELECT top(value, 1) FROM cpu`},
-		{s: `SELECT top(value, host, 1) FROM cpu`},
-		{s: `SELECT top(value, 1), host FROM cpu`},
-		{s: `SELECT min(top) FROM (SELECT top(value, host, 1) FROM cpu) GROUP BY region`},
-		{s: `SELECT bottom(value, 1) FROM cpu`},
-		{s: `SELECT bottom(value, host, 1) FROM cpu`},
-		{s: `SELECT bottom(value, 1), host FROM cpu`},
-		{s: `SELECT max(bottom) FROM (SELECT bottom(value, host, 1) FROM cpu) GROUP BY region`},
+		{s: `SELECT top(value, 1) FROM cpu`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, host, 1) FROM cpu`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, 1), host FROM cpu`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT min(top) FROM (SELECT top(value, host, 1) FROM cpu) GROUP BY region`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom(value, 1) FROM cpu`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, host, 1) FROM cpu`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, 1), host FROM cpu`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT max(bottom) FROM (SELECT bottom(value, host, 1) FROM cpu) GROUP BY region`, unimplemented: `unimplemented: function bottom`},
 		{s: `SELECT percentile(value, 75) FROM cpu`},
 		{s: `SELECT percentile(value, 75.0) FROM cpu`},
 		{s: `SELECT median(value) FROM cpu`},
 		{s: `SELECT sample(value, 2) FROM cpu`},
-		{s: `SELECT sample(*, 2) FROM cpu`},
-		{s: `SELECT sample(/val/, 2) FROM cpu`},
-		{s: `SELECT elapsed(value) FROM cpu`},
-		{s: `SELECT elapsed(value, 10s) FROM cpu`},
-		{s: `SELECT integral(value) FROM cpu`},
-		{s: `SELECT integral(value, 10s) FROM cpu`},
+		{s: `SELECT sample(*, 2) FROM cpu`, unimplemented: `unimplemented: wildcard function`},
+		{s: `SELECT sample(/val/, 2) FROM cpu`, unimplemented: `unimplemented: wildcard regex function`},
+		{s: `SELECT sample(value, 2), mean(value) FROM cpu`, err: `unimplemented: sample() with other functions`},
+		{s: `SELECT elapsed(value) FROM cpu`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value, 10s) FROM cpu`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT integral(value) FROM cpu`, unimplemented: `unimplemented: function integral`},
+		{s: `SELECT integral(value, 10s) FROM cpu`, unimplemented: `unimplemented: function integral`},
 		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, 5s)`},
 		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, '2000-01-01T00:00:05Z')`},
 		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1m GROUP BY time(10s, now())`},
 		{s: `SELECT max(mean) FROM (SELECT mean(value) FROM cpu GROUP BY host)`},
-		{s: `SELECT max(derivative) FROM (SELECT derivative(mean(value)) FROM cpu) WHERE time >= now() - 1m GROUP BY time(10s)`},
+		{s: `SELECT max(derivative) FROM (SELECT derivative(mean(value)) FROM cpu) WHERE time >= now() - 1m GROUP BY time(10s)`, unimplemented: `unimplemented: function derivative`},
 		{s: `SELECT max(value) FROM (SELECT value + total FROM cpu) WHERE time >= now() - 1m GROUP BY time(10s)`},
 		{s: `SELECT value FROM cpu WHERE time >= '2000-01-01T00:00:00Z' AND time <= '2000-01-01T01:00:00Z'`},
 		{s: `SELECT value FROM (SELECT value FROM cpu) ORDER BY time DESC`},
-		{s: `SELECT count(distinct(value)), max(value) FROM cpu`},
-		{s: `SELECT derivative(distinct(value)), difference(distinct(value)) FROM cpu WHERE time >= now() - 1m GROUP BY time(5s)`},
+		{s: `SELECT derivative(distinct(value)), difference(distinct(value)) FROM cpu WHERE time >= now() - 1m GROUP BY time(5s)`, unimplemented: `unimplemented: function derivative`},
 		{s: `SELECT moving_average(distinct(value), 3) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`},
-		{s: `SELECT elapsed(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`},
-		{s: `SELECT cumulative_sum(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`},
+		{s: `SELECT elapsed(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT cumulative_sum(distinct(value)) FROM cpu WHERE time >= now() - 5m GROUP BY time(1m)`, unimplemented: `unimplemented: function cumulative_sum`},
 		{s: `SELECT last(value) / (1 - 0) FROM cpu`},
-		{s: `SELECT abs(value) FROM cpu`},
-		{s: `SELECT sin(value) FROM cpu`},
-		{s: `SELECT cos(value) FROM cpu`},
-		{s: `SELECT tan(value) FROM cpu`},
-		{s: `SELECT asin(value) FROM cpu`},
-		{s: `SELECT acos(value) FROM cpu`},
-		{s: `SELECT atan(value) FROM cpu`},
-		{s: `SELECT sqrt(value) FROM cpu`},
-		{s: `SELECT pow(value, 2) FROM cpu`},
-		{s: `SELECT pow(value, 3.14) FROM cpu`},
-		{s: `SELECT pow(2, value) FROM cpu`},
-		{s: `SELECT pow(3.14, value) FROM cpu`},
-		{s: `SELECT exp(value) FROM cpu`},
-		{s: `SELECT atan2(value, 0.1) FROM cpu`},
-		{s: `SELECT atan2(0.2, value) FROM cpu`},
-		{s: `SELECT atan2(value, 1) FROM cpu`},
-		{s: `SELECT atan2(2, value) FROM cpu`},
-		{s: `SELECT ln(value) FROM cpu`},
-		{s: `SELECT log(value, 2) FROM cpu`},
-		{s: `SELECT log2(value) FROM cpu`},
-		{s: `SELECT log10(value) FROM cpu`},
-		{s: `SELECT sin(value) - sin(1.3) FROM cpu`},
-		{s: `SELECT value FROM cpu WHERE sin(value) > 0.5`},
+		{s: `SELECT abs(value) FROM cpu`, unimplemented: `unimplemented: function abs`},
+		{s: `SELECT sin(value) FROM cpu`, unimplemented: `unimplemented: function sin`},
+		{s: `SELECT cos(value) FROM cpu`, unimplemented: `unimplemented: function cos`},
+		{s: `SELECT tan(value) FROM cpu`, unimplemented: `unimplemented: function tan`},
+		{s: `SELECT asin(value) FROM cpu`, unimplemented: `unimplemented: function asin`},
+		{s: `SELECT acos(value) FROM cpu`, unimplemented: `unimplemented: function acos`},
+		{s: `SELECT atan(value) FROM cpu`, unimplemented: `unimplemented: function atan`},
+		{s: `SELECT sqrt(value) FROM cpu`, unimplemented: `unimplemented: function sqrt`},
+		{s: `SELECT pow(value, 2) FROM cpu`, unimplemented: `unimplemented: function pow`},
+		{s: `SELECT pow(value, 3.14) FROM cpu`, unimplemented: `unimplemented: function pow`},
+		{s: `SELECT pow(2, value) FROM cpu`, unimplemented: `unimplemented: function pow`},
+		{s: `SELECT pow(3.14, value) FROM cpu`, unimplemented: `unimplemented: function pow`},
+		{s: `SELECT exp(value) FROM cpu`, unimplemented: `unimplemented: function exp`},
+		{s: `SELECT atan2(value, 0.1) FROM cpu`, unimplemented: `unimplemented: function atan2`},
+		{s: `SELECT atan2(0.2, value) FROM cpu`, unimplemented: `unimplemented: function atan2`},
+		{s: `SELECT atan2(value, 1) FROM cpu`, unimplemented: `unimplemented: function atan2`},
+		{s: `SELECT atan2(2, value) FROM cpu`, unimplemented: `unimplemented: function atan2`},
+		{s: `SELECT ln(value) FROM cpu`, unimplemented: `unimplemented: function ln`},
+		{s: `SELECT log(value, 2) FROM cpu`, unimplemented: `unimplemented: function log`},
+		{s: `SELECT log2(value) FROM cpu`, unimplemented: `unimplemented: function log2`},
+		{s: `SELECT log10(value) FROM cpu`, unimplemented: `unimplemented: function log10`},
+		{s: `SELECT sin(value) - sin(1.3) FROM cpu`, unimplemented: `unimplemented: function sin`},
+		{s: `SELECT value FROM cpu WHERE sin(value) > 0.5`, unimplemented: `unable to evaluate condition: unimplemented math function: "sin"`},
 		{s: `SELECT time FROM cpu`, err: `unable to transpile: at least one non-time field must be queried`},
+		{s: `SELECT time, time FROM cpu`, err: `unable to transpile: at least one non-time field must be queried`},
+		{s: `SELECT time AS t FROM cpu WHERE host = 'server01'`, err: `unable to transpile: at least one non-time field must be queried`},
+		{s: `SELECT time FROM cpu WHERE time >= now() - 1h GROUP BY host`, err: `unable to transpile: at least one non-time field must be queried`},
+		{s: `SELECT value, 2 * 3 FROM db0..cpu`, err: `field must contain at least one variable`},
+		{s: `SELECT value, 'a' FROM cpu`, err: `field must contain at least one variable`},
 		{s: `SELECT value, mean(value) FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT value, max(value), min(value) FROM cpu`, err: `mixing multiple selector functions with tags or fields is not supported`},
-		{s: `SELECT top(value, 10), max(value) FROM cpu`, err: `selector function top() cannot be combined with other functions`},
-		{s: `SELECT bottom(value, 10), max(value) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`},
+		{s: `SELECT top(value, 10), max(value) FROM cpu`, err: `selector function top() cannot be combined with other functions`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom(value, 10), max(value) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`, unimplemented: `unimplemented: function bottom`},
 		{s: `SELECT count() FROM cpu`, err: `invalid number of arguments for count, expected 1, got 0`},
 		{s: `SELECT count(value, host) FROM cpu`, err: `invalid number of arguments for count, expected 1, got 2`},
 		{s: `SELECT min() FROM cpu`, err: `invalid number of arguments for min, expected 1, got 0`},
@@ -165,9 +185,11 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT mean() FROM cpu`, err: `invalid number of arguments for mean, expected 1, got 0`},
 		{s: `SELECT mean(value, host) FROM cpu`, err: `invalid number of arguments for mean, expected 1, got 2`},
 		{s: `SELECT distinct(value), max(value) FROM cpu`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
-		{s: `SELECT count(distinct()) FROM cpu`, err: `distinct function requires at least one argument`},
-		{s: `SELECT count(distinct(value, host)) FROM cpu`, err: `distinct function can only have one argument`},
-		{s: `SELECT count(distinct(2)) FROM cpu`, err: `expected field argument in distinct()`},
+		{s: `SELECT count(distinct(value)), max(value) FROM cpu`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
+		{s: `SELECT count(distinct value), max(value) FROM cpu`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
+		{s: `SELECT count(distinct()) FROM cpu`, err: `distinct function requires at least one argument`, unimplemented: `unimplemented: count(distinct)`},
+		{s: `SELECT count(distinct(value, host)) FROM cpu`, err: `distinct function can only have one argument`, unimplemented: `unimplemented: count(distinct)`},
+		{s: `SELECT count(distinct(2)) FROM cpu`, err: `expected field argument in distinct()`, unimplemented: `unimplemented: count(distinct)`},
 		{s: `SELECT value FROM cpu GROUP BY now()`, err: `only time() calls allowed in dimensions`},
 		{s: `SELECT value FROM cpu GROUP BY time()`, err: `time dimension expected 1 or 2 arguments`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m, 30s, 1ms)`, err: `time dimension expected 1 or 2 arguments`},
@@ -177,20 +199,21 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT value FROM cpu GROUP BY time(5m, now(1m))`, err: `time dimension offset now() function requires no arguments`},
 		{s: `SELECT value FROM cpu GROUP BY time(5m, 'unexpected')`, err: `time dimension offset must be duration or now()`},
 		{s: `SELECT value FROM cpu GROUP BY 'unexpected'`, err: `only time and tag dimensions allowed`},
-		{s: `SELECT top(value) FROM cpu`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT top('unexpected', 5) FROM cpu`, err: `expected first argument to be a field in top(), found 'unexpected'`},
-		{s: `SELECT top(value, 'unexpected', 5) FROM cpu`, err: `only fields or tags are allowed in top(), found 'unexpected'`},
-		{s: `SELECT top(value, 2.5) FROM cpu`, err: `expected integer as last argument in top(), found 2.500`},
-		{s: `SELECT top(value, -1) FROM cpu`, err: `limit (-1) in top function must be at least 1`},
-		{s: `SELECT top(value, 3) FROM cpu LIMIT 2`, err: `limit (3) in top function can not be larger than the LIMIT (2) in the select statement`},
-		{s: `SELECT bottom(value) FROM cpu`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT bottom('unexpected', 5) FROM cpu`, err: `expected first argument to be a field in bottom(), found 'unexpected'`},
-		{s: `SELECT bottom(value, 'unexpected', 5) FROM cpu`, err: `only fields or tags are allowed in bottom(), found 'unexpected'`},
-		{s: `SELECT bottom(value, 2.5) FROM cpu`, err: `expected integer as last argument in bottom(), found 2.500`},
-		{s: `SELECT bottom(value, -1) FROM cpu`, err: `limit (-1) in bottom function must be at least 1`},
-		{s: `SELECT bottom(value, 3) FROM cpu LIMIT 2`, err: `limit (3) in bottom function can not be larger than the LIMIT (2) in the select statement`},
-		// TODO(jsternberg): This query is wrong, but we cannot enforce this because of previous behavior: https://github.com/influxdata/influxdb/pull/8771
-		//{s: `SELECT value FROM cpu WHERE time >= now() - 10m OR time < now() - 5m`, err: `cannot use OR with time conditions`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY host - region`, err: `only time and tag dimensions allowed`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY host + 1`, err: `only time and tag dimensions allowed`},
+		{s: `SELECT top(value) FROM cpu`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top('unexpected', 5) FROM cpu`, err: `expected first argument to be a field in top(), found 'unexpected'`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, 'unexpected', 5) FROM cpu`, err: `only fields or tags are allowed in top(), found 'unexpected'`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, 2.5) FROM cpu`, err: `expected integer as last argument in top(), found 2.500`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, -1) FROM cpu`, err: `limit (-1) in top function must be at least 1`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, 3) FROM cpu LIMIT 2`, err: `limit (3) in top function can not be larger than the LIMIT (2) in the select statement`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom(value) FROM cpu`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom('unexpected', 5) FROM cpu`, err: `expected first argument to be a field in bottom(), found 'unexpected'`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, 'unexpected', 5) FROM cpu`, err: `only fields or tags are allowed in bottom(), found 'unexpected'`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, 2.5) FROM cpu`, err: `expected integer as last argument in bottom(), found 2.500`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, -1) FROM cpu`, err: `limit (-1) in bottom function must be at least 1`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, 3) FROM cpu LIMIT 2`, err: `limit (3) in bottom function can not be larger than the LIMIT (2) in the select statement`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT value FROM cpu WHERE time >= now() - 10m OR time < now() - 5m`},
 		{s: `SELECT value FROM cpu WHERE value`, err: `invalid condition expression: value`},
 		{s: `SELECT count(value), * FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT max(*), host FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
@@ -198,7 +221,7 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT max(/val/), * FROM cpu`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT a(value) FROM cpu`, err: `undefined function a()`},
 		{s: `SELECT count(max(value)) FROM myseries`, err: `expected field argument in count()`},
-		{s: `SELECT count(distinct('value')) FROM myseries`, err: `expected field argument in distinct()`},
+		{s: `SELECT count(distinct('value')) FROM myseries`, err: `expected field argument in distinct()`, unimplemented: `unimplemented: count(distinct)`},
 		{s: `SELECT distinct('value') FROM myseries`, err: `expected field argument in distinct()`},
 		{s: `SELECT min(max(value)) FROM myseries`, err: `expected field argument in min()`},
 		{s: `SELECT min(distinct(value)) FROM myseries`, err: `expected field argument in min()`},
@@ -208,30 +231,31 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT last(max(value)) FROM myseries`, err: `expected field argument in last()`},
 		{s: `SELECT mean(max(value)) FROM myseries`, err: `expected field argument in mean()`},
 		{s: `SELECT median(max(value)) FROM myseries`, err: `expected field argument in median()`},
-		{s: `SELECT mode(max(value)) FROM myseries`, err: `expected field argument in mode()`},
+		{s: `SELECT mode(max(value)) FROM myseries`, err: `expected field argument in mode()`, unimplemented: `unimplemented: function mode`},
 		{s: `SELECT stddev(max(value)) FROM myseries`, err: `expected field argument in stddev()`},
 		{s: `SELECT spread(max(value)) FROM myseries`, err: `expected field argument in spread()`},
-		{s: `SELECT top() FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 0`},
-		{s: `SELECT top(field1) FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT top(field1,foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`},
-		{s: `SELECT top(field1,host,'server',foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`},
-		{s: `SELECT top(field1,5,'server',2) FROM myseries`, err: `only fields or tags are allowed in top(), found 5`},
-		{s: `SELECT top(field1,max(foo),'server',2) FROM myseries`, err: `only fields or tags are allowed in top(), found max(foo)`},
-		{s: `SELECT top(value, 10) + count(value) FROM myseries`, err: `selector function top() cannot be combined with other functions`},
-		{s: `SELECT top(max(value), 10) FROM myseries`, err: `expected first argument to be a field in top(), found max(value)`},
-		{s: `SELECT bottom() FROM myseries`, err: `invalid number of arguments for bottom, expected at least 2, got 0`},
-		{s: `SELECT bottom(field1) FROM myseries`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT bottom(field1,foo) FROM myseries`, err: `expected integer as last argument in bottom(), found foo`},
-		{s: `SELECT bottom(field1,host,'server',foo) FROM myseries`, err: `expected integer as last argument in bottom(), found foo`},
-		{s: `SELECT bottom(field1,5,'server',2) FROM myseries`, err: `only fields or tags are allowed in bottom(), found 5`},
-		{s: `SELECT bottom(field1,max(foo),'server',2) FROM myseries`, err: `only fields or tags are allowed in bottom(), found max(foo)`},
-		{s: `SELECT bottom(value, 10) + count(value) FROM myseries`, err: `selector function bottom() cannot be combined with other functions`},
-		{s: `SELECT bottom(max(value), 10) FROM myseries`, err: `expected first argument to be a field in bottom(), found max(value)`},
-		{s: `SELECT top(value, 10), bottom(value, 10) FROM cpu`, err: `selector function top() cannot be combined with other functions`},
-		{s: `SELECT bottom(value, 10), top(value, 10) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`},
+		{s: `SELECT top() FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 0`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(field1) FROM myseries`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(field1,foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(field1,host,'server',foo) FROM myseries`, err: `expected integer as last argument in top(), found foo`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(field1,5,'server',2) FROM myseries`, err: `only fields or tags are allowed in top(), found 5`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(field1,max(foo),'server',2) FROM myseries`, err: `only fields or tags are allowed in top(), found max(foo)`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(value, 10) + count(value) FROM myseries`, err: `selector function top() cannot be combined with other functions`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT top(max(value), 10) FROM myseries`, err: `expected first argument to be a field in top(), found max(value)`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom() FROM myseries`, err: `invalid number of arguments for bottom, expected at least 2, got 0`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(field1) FROM myseries`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(field1,foo) FROM myseries`, err: `expected integer as last argument in bottom(), found foo`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(field1,host,'server',foo) FROM myseries`, err: `expected integer as last argument in bottom(), found foo`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(field1,5,'server',2) FROM myseries`, err: `only fields or tags are allowed in bottom(), found 5`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(field1,max(foo),'server',2) FROM myseries`, err: `only fields or tags are allowed in bottom(), found max(foo)`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(value, 10) + count(value) FROM myseries`, err: `selector function bottom() cannot be combined with other functions`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT bottom(max(value), 10) FROM myseries`, err: `expected first argument to be a field in bottom(), found max(value)`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT top(value, 10), bottom(value, 10) FROM cpu`, err: `selector function top() cannot be combined with other functions`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom(value, 10), top(value, 10) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`, unimplemented: `unimplemented: function bottom`},
 		{s: `SELECT sample(value) FROM myseries`, err: `invalid number of arguments for sample, expected 2, got 1`},
 		{s: `SELECT sample(value, 2, 3) FROM myseries`, err: `invalid number of arguments for sample, expected 2, got 3`},
 		{s: `SELECT sample(value, 0) FROM myseries`, err: `sample window must be greater than 1, got 0`},
+		{s: `SELECT sample(value, 1) FROM myseries`, err: `sample window must be greater than 1, got 1`},
 		{s: `SELECT sample(value, 2.5) FROM myseries`, err: `expected integer argument in sample()`},
 		{s: `SELECT percentile() FROM myseries`, err: `invalid number of arguments for percentile, expected 2, got 0`},
 		{s: `SELECT percentile(field1) FROM myseries`, err: `invalid number of arguments for percentile, expected 2, got 1`},
@@ -254,31 +278,31 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT distinct() FROM myseries`, err: `distinct function requires at least one argument`},
 		{s: `SELECT distinct field1, field2 FROM myseries`, err: `aggregate function distinct() cannot be combined with other functions or fields`},
 		{s: `SELECT count(distinct field1, field2) FROM myseries`, err: `invalid number of arguments for count, expected 1, got 2`},
-		{s: `select count(distinct(too, many, arguments)) from myseries`, err: `distinct function can only have one argument`},
+		{s: `select count(distinct(too, many, arguments)) from myseries`, err: `distinct function can only have one argument`, unimplemented: `unimplemented: count(distinct)`},
 		{s: `select count() from myseries`, err: `invalid number of arguments for count, expected 1, got 0`},
-		{s: `SELECT derivative(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
-		{s: `select derivative() from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 0`},
-		{s: `select derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 3`},
-		{s: `SELECT derivative(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to derivative`},
-		{s: `SELECT derivative(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT derivative(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
-		{s: `SELECT derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
-		{s: `SELECT derivative(mean(value), 1h) FROM myseries where time < now() and time > now() - 1d`, err: `derivative aggregate requires a GROUP BY interval`},
-		{s: `SELECT derivative(value, -2h) FROM myseries`, err: `duration argument must be positive, got -2h`},
-		{s: `SELECT derivative(value, 10) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.IntegerLiteral`},
-		{s: `SELECT derivative(f, true) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.BooleanLiteral`},
-		{s: `SELECT non_negative_derivative(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
-		{s: `select non_negative_derivative() from myseries`, err: `invalid number of arguments for non_negative_derivative, expected at least 1 but no more than 2, got 0`},
-		{s: `select non_negative_derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for non_negative_derivative, expected at least 1 but no more than 2, got 3`},
-		{s: `SELECT non_negative_derivative(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to non_negative_derivative`},
-		{s: `SELECT non_negative_derivative(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT non_negative_derivative(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT non_negative_derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
-		{s: `SELECT non_negative_derivative(mean(value), 1h) FROM myseries where time < now() and time > now() - 1d`, err: `non_negative_derivative aggregate requires a GROUP BY interval`},
-		{s: `SELECT non_negative_derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
-		{s: `SELECT non_negative_derivative(value, -2h) FROM myseries`, err: `duration argument must be positive, got -2h`},
-		{s: `SELECT non_negative_derivative(value, 10) FROM myseries`, err: `second argument to non_negative_derivative must be a duration, got *influxql.IntegerLiteral`},
+		{s: `SELECT derivative(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`, unimplemented: `unimplemented: function derivative`},
+		{s: `select derivative() from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 0`, unimplemented: `unimplemented: function derivative`},
+		{s: `select derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for derivative, expected at least 1 but no more than 2, got 3`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to derivative`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(mean(value), 1h) FROM myseries where time < now() and time > now() - 1d`, err: `derivative aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(value, -2h) FROM myseries`, err: `duration argument must be positive, got -2h`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(value, 10) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.IntegerLiteral`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT derivative(f, true) FROM myseries`, err: `second argument to derivative must be a duration, got *influxql.BooleanLiteral`, unimplemented: `unimplemented: function derivative`},
+		{s: `SELECT non_negative_derivative(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `select non_negative_derivative() from myseries`, err: `invalid number of arguments for non_negative_derivative, expected at least 1 but no more than 2, got 0`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `select non_negative_derivative(mean(value), 1h, 3) from myseries`, err: `invalid number of arguments for non_negative_derivative, expected at least 1 but no more than 2, got 3`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to non_negative_derivative`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(mean(value), 1h) FROM myseries where time < now() and time > now() - 1d`, err: `non_negative_derivative aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(value, -2h) FROM myseries`, err: `duration argument must be positive, got -2h`, unimplemented: `unimplemented: function non_negative_derivative`},
+		{s: `SELECT non_negative_derivative(value, 10) FROM myseries`, err: `second argument to non_negative_derivative must be a duration, got *influxql.IntegerLiteral`, unimplemented: `unimplemented: function non_negative_derivative`},
 		{s: `SELECT difference(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT difference() from myseries`, err: `invalid number of arguments for difference, expected 1, got 0`},
 		// TODO(ethan): https://github.com/influxdata/influxdb/issues/17115
@@ -288,25 +312,25 @@ func TestTranspiler_Compile(t *testing.T) {
 		//{s: `SELECT difference(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		//{s: `SELECT difference(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		//{s: `SELECT difference(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `difference aggregate requires a GROUP BY interval`},
-		{s: `SELECT non_negative_difference(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
-		{s: `SELECT non_negative_difference() from myseries`, err: `invalid number of arguments for non_negative_difference, expected 1, got 0`},
-		{s: `SELECT non_negative_difference(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to non_negative_difference`},
-		{s: `SELECT non_negative_difference(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT non_negative_difference(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT non_negative_difference(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
-		{s: `SELECT non_negative_difference(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
-		{s: `SELECT non_negative_difference(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `non_negative_difference aggregate requires a GROUP BY interval`},
-		{s: `SELECT elapsed() FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 0`},
-		{s: `SELECT elapsed(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to elapsed`},
-		{s: `SELECT elapsed(value, 1s, host) FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 3`},
-		{s: `SELECT elapsed(value, 0s) FROM myseries`, err: `duration argument must be positive, got 0s`},
-		{s: `SELECT elapsed(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`},
-		{s: `SELECT elapsed(value, 10) FROM myseries`, err: `second argument to elapsed must be a duration, got *influxql.IntegerLiteral`},
-		{s: `SELECT elapsed(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT elapsed(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT elapsed(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
-		{s: `SELECT elapsed(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
-		{s: `SELECT elapsed(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `elapsed aggregate requires a GROUP BY interval`},
+		{s: `SELECT non_negative_difference(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference() from myseries`, err: `invalid number of arguments for non_negative_difference, expected 1, got 0`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to non_negative_difference`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT non_negative_difference(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `non_negative_difference aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function non_negative_difference`},
+		{s: `SELECT elapsed() FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 0`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to elapsed`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value, 1s, host) FROM myseries`, err: `invalid number of arguments for elapsed, expected at least 1 but no more than 2, got 3`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value, 0s) FROM myseries`, err: `duration argument must be positive, got 0s`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(value, 10) FROM myseries`, err: `second argument to elapsed must be a duration, got *influxql.IntegerLiteral`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`, unimplemented: `unimplemented: function elapsed`},
+		{s: `SELECT elapsed(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `elapsed aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function elapsed`},
 		{s: `SELECT moving_average(field1, 2), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		{s: `SELECT moving_average(field1, 1), field1 FROM myseries`, err: `moving_average window must be greater than 1, got 1`},
 		{s: `SELECT moving_average(field1, 0), field1 FROM myseries`, err: `moving_average window must be greater than 1, got 0`},
@@ -315,67 +339,75 @@ func TestTranspiler_Compile(t *testing.T) {
 		{s: `SELECT moving_average() from myseries`, err: `invalid number of arguments for moving_average, expected 2, got 0`},
 		{s: `SELECT moving_average(value) FROM myseries`, err: `invalid number of arguments for moving_average, expected 2, got 1`},
 		{s: `SELECT moving_average(value, 2) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to moving_average`},
-		{s: `SELECT moving_average(top(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT moving_average(bottom(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
+		{s: `SELECT moving_average(top(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT moving_average(bottom(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function bottom`},
 		{s: `SELECT moving_average(max(), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
 		{s: `SELECT moving_average(percentile(value), 2) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
 		{s: `SELECT moving_average(mean(value), 2) FROM myseries where time < now() and time > now() - 1d`, err: `moving_average aggregate requires a GROUP BY interval`},
-		{s: `SELECT cumulative_sum(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`},
-		{s: `SELECT cumulative_sum() from myseries`, err: `invalid number of arguments for cumulative_sum, expected 1, got 0`},
-		{s: `SELECT cumulative_sum(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to cumulative_sum`},
-		{s: `SELECT cumulative_sum(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`},
-		{s: `SELECT cumulative_sum(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`},
-		{s: `SELECT cumulative_sum(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`},
-		{s: `SELECT cumulative_sum(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`},
-		{s: `SELECT cumulative_sum(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `cumulative_sum aggregate requires a GROUP BY interval`},
-		{s: `SELECT integral() FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 0`},
-		{s: `SELECT integral(value, 10s, host) FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 3`},
-		{s: `SELECT integral(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`},
-		{s: `SELECT integral(value, 10) FROM myseries`, err: `second argument must be a duration`},
-		{s: `SELECT holt_winters(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters, expected 3, got 1`},
-		{s: `SELECT holt_winters(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters`},
-		{s: `SELECT holt_winters(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters aggregate requires a GROUP BY interval`},
-		{s: `SELECT holt_winters(min(value), 0, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `second arg to holt_winters must be greater than 0, got 0`},
-		{s: `SELECT holt_winters(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters`},
-		{s: `SELECT holt_winters(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters`},
-		{s: `SELECT holt_winters(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters cannot be negative, got -1`},
-		{s: `SELECT holt_winters_with_fit(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters_with_fit, expected 3, got 1`},
-		{s: `SELECT holt_winters_with_fit(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters_with_fit`},
-		{s: `SELECT holt_winters_with_fit(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters_with_fit aggregate requires a GROUP BY interval`},
-		{s: `SELECT holt_winters_with_fit(min(value), 0, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `second arg to holt_winters_with_fit must be greater than 0, got 0`},
-		{s: `SELECT holt_winters_with_fit(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters_with_fit`},
-		{s: `SELECT holt_winters_with_fit(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters_with_fit`},
-		{s: `SELECT holt_winters_with_fit(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters_with_fit cannot be negative, got -1`},
+		{s: `SELECT cumulative_sum(field1), field1 FROM myseries`, err: `mixing aggregate and non-aggregate queries is not supported`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum() from myseries`, err: `invalid number of arguments for cumulative_sum, expected 1, got 0`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(value) FROM myseries group by time(1h)`, err: `aggregate function required inside the call to cumulative_sum`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(top(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for top, expected at least 2, got 1`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(bottom(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for bottom, expected at least 2, got 1`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(max()) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for max, expected 1, got 0`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(percentile(value)) FROM myseries where time < now() and time > now() - 1d group by time(1h)`, err: `invalid number of arguments for percentile, expected 2, got 1`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT cumulative_sum(mean(value)) FROM myseries where time < now() and time > now() - 1d`, err: `cumulative_sum aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function cumulative_sum`},
+		{s: `SELECT integral() FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 0`, unimplemented: `unimplemented: function integral`},
+		{s: `SELECT integral(value, 10s, host) FROM myseries`, err: `invalid number of arguments for integral, expected at least 1 but no more than 2, got 3`, unimplemented: `unimplemented: function integral`},
+		{s: `SELECT integral(value, -10s) FROM myseries`, err: `duration argument must be positive, got -10s`, unimplemented: `unimplemented: function integral`},
+		{s: `SELECT integral(value, 10) FROM myseries`, err: `second argument must be a duration`, unimplemented: `unimplemented: function integral`},
+		{s: `SELECT holt_winters(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters, expected 3, got 1`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(min(value), 0, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `second arg to holt_winters must be greater than 0, got 0`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters cannot be negative, got -1`, unimplemented: `unimplemented: function holt_winters`},
+		{s: `SELECT holt_winters_with_fit(value) FROM myseries where time < now() and time > now() - 1d`, err: `invalid number of arguments for holt_winters_with_fit, expected 3, got 1`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(value, 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `must use aggregate function with holt_winters_with_fit`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(min(value), 10, 2) FROM myseries where time < now() and time > now() - 1d`, err: `holt_winters_with_fit aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(min(value), 0, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `second arg to holt_winters_with_fit must be greater than 0, got 0`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(min(value), false, 2) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as second arg in holt_winters_with_fit`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(min(value), 10, 'string') FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `expected integer argument as third arg in holt_winters_with_fit`, unimplemented: `unimplemented: function holt_winters_with_fit`},
+		{s: `SELECT holt_winters_with_fit(min(value), 10, -1) FROM myseries where time < now() and time > now() - 1d GROUP BY time(1d)`, err: `third arg to holt_winters_with_fit cannot be negative, got -1`, unimplemented: `unimplemented: function holt_winters_with_fit`},
 		{s: `SELECT mean(value) + value FROM cpu WHERE time < now() and time > now() - 1h GROUP BY time(10m)`, err: `mixing aggregate and non-aggregate queries is not supported`},
 		// TODO: Remove this restriction in the future: https://github.com/influxdata/influxdb/issues/5968
 		{s: `SELECT mean(cpu_total - cpu_idle) FROM cpu`, err: `expected field argument in mean()`},
-		{s: `SELECT derivative(mean(cpu_total - cpu_idle), 1s) FROM cpu WHERE time < now() AND time > now() - 1d GROUP BY time(1h)`, err: `expected field argument in mean()`},
+		{s: `SELECT derivative(mean(cpu_total - cpu_idle), 1s) FROM cpu WHERE time < now() AND time > now() - 1d GROUP BY time(1h)`, err: `expected field argument in mean()`, unimplemented: `unimplemented: function derivative`},
 		// TODO: The error message will change when math is allowed inside an aggregate: https://github.com/influxdata/influxdb/pull/5990#issuecomment-195565870
 		{s: `SELECT count(foo + sum(bar)) FROM cpu`, err: `expected field argument in count()`},
 		{s: `SELECT (count(foo + sum(bar))) FROM cpu`, err: `expected field argument in count()`},
 		{s: `SELECT sum(value) + count(foo + sum(bar)) FROM cpu`, err: `expected field argument in count()`},
-		{s: `SELECT top(value, 2), max(value) FROM cpu`, err: `selector function top() cannot be combined with other functions`},
-		{s: `SELECT bottom(value, 2), max(value) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`},
-		{s: `SELECT min(derivative) FROM (SELECT derivative(mean(value), 1h) FROM myseries) where time < now() and time > now() - 1d`, err: `derivative aggregate requires a GROUP BY interval`},
+		{s: `SELECT top(value, 2), max(value) FROM cpu`, err: `selector function top() cannot be combined with other functions`, unimplemented: `unimplemented: function top`},
+		{s: `SELECT bottom(value, 2), max(value) FROM cpu`, err: `selector function bottom() cannot be combined with other functions`, unimplemented: `unimplemented: function bottom`},
+		{s: `SELECT min(derivative) FROM (SELECT derivative(mean(value), 1h) FROM myseries) where time < now() and time > now() - 1d`, err: `derivative aggregate requires a GROUP BY interval`, unimplemented: `unimplemented: function derivative`},
 		{s: `SELECT min(mean) FROM (SELECT mean(value) FROM myseries GROUP BY time)`, err: `time() is a function and expects at least one argument`},
 		{s: `SELECT value FROM myseries WHERE value OR time >= now() - 1m`, err: `invalid condition expression: value`},
 		{s: `SELECT value FROM myseries WHERE time >= now() - 1m OR value`, err: `invalid condition expression: value`},
 		{s: `SELECT value FROM (SELECT value FROM cpu ORDER BY time DESC) ORDER BY time ASC`, err: `subqueries must be ordered in the same direction as the query itself`},
-		{s: `SELECT sin(value, 3) FROM cpu`, err: `invalid number of arguments for sin, expected 1, got 2`},
-		{s: `SELECT cos(2.3, value, 3) FROM cpu`, err: `invalid number of arguments for cos, expected 1, got 3`},
-		{s: `SELECT tan(value, 3) FROM cpu`, err: `invalid number of arguments for tan, expected 1, got 2`},
-		{s: `SELECT asin(value, 3) FROM cpu`, err: `invalid number of arguments for asin, expected 1, got 2`},
-		{s: `SELECT acos(value, 3.2) FROM cpu`, err: `invalid number of arguments for acos, expected 1, got 2`},
-		{s: `SELECT atan() FROM cpu`, err: `invalid number of arguments for atan, expected 1, got 0`},
-		{s: `SELECT sqrt(42, 3, 4) FROM cpu`, err: `invalid number of arguments for sqrt, expected 1, got 3`},
-		{s: `SELECT abs(value, 3) FROM cpu`, err: `invalid number of arguments for abs, expected 1, got 2`},
-		{s: `SELECT ln(value, 3) FROM cpu`, err: `invalid number of arguments for ln, expected 1, got 2`},
-		{s: `SELECT log2(value, 3) FROM cpu`, err: `invalid number of arguments for log2, expected 1, got 2`},
-		{s: `SELECT log10(value, 3) FROM cpu`, err: `invalid number of arguments for log10, expected 1, got 2`},
-		{s: `SELECT pow(value, 3, 3) FROM cpu`, err: `invalid number of arguments for pow, expected 2, got 3`},
-		{s: `SELECT atan2(value, 3, 3) FROM cpu`, err: `invalid number of arguments for atan2, expected 2, got 3`},
-		{s: `SELECT sin(1.3) FROM cpu`, err: `field must contain at least one variable`},
+		{s: `SELECT sin(value, 3) FROM cpu`, err: `invalid number of arguments for sin, expected 1, got 2`, unimplemented: `unimplemented: function sin`},
+		{s: `SELECT cos(2.3, value, 3) FROM cpu`, err: `invalid number of arguments for cos, expected 1, got 3`, unimplemented: `unimplemented: function cos`},
+		{s: `SELECT tan(value, 3) FROM cpu`, err: `invalid number of arguments for tan, expected 1, got 2`, unimplemented: `unimplemented: function tan`},
+		{s: `SELECT asin(value, 3) FROM cpu`, err: `invalid number of arguments for asin, expected 1, got 2`, unimplemented: `unimplemented: function asin`},
+		{s: `SELECT acos(value, 3.2) FROM cpu`, err: `invalid number of arguments for acos, expected 1, got 2`, unimplemented: `unimplemented: function acos`},
+		{s: `SELECT atan() FROM cpu`, err: `invalid number of arguments for atan, expected 1, got 0`, unimplemented: `unimplemented: function atan`},
+		{s: `SELECT sqrt(42, 3, 4) FROM cpu`, err: `invalid number of arguments for sqrt, expected 1, got 3`, unimplemented: `unimplemented: function sqrt`},
+		{s: `SELECT abs(value, 3) FROM cpu`, err: `invalid number of arguments for abs, expected 1, got 2`, unimplemented: `unimplemented: function abs`},
+		{s: `SELECT ln(value, 3) FROM cpu`, err: `invalid number of arguments for ln, expected 1, got 2`, unimplemented: `unimplemented: function ln`},
+		{s: `SELECT log2(value, 3) FROM cpu`, err: `invalid number of arguments for log2, expected 1, got 2`, unimplemented: `unimplemented: function log2`},
+		{s: `SELECT log10(value, 3) FROM cpu`, err: `invalid number of arguments for log10, expected 1, got 2`, unimplemented: `unimplemented: function log10`},
+		{s: `SELECT pow(value, 3, 3) FROM cpu`, err: `invalid number of arguments for pow, expected 2, got 3`, unimplemented: `unimplemented: function pow`},
+		{s: `SELECT atan2(value, 3, 3) FROM cpu`, err: `invalid number of arguments for atan2, expected 2, got 3`, unimplemented: `unimplemented: function atan2`},
+		{s: `SELECT sin(1.3) FROM cpu`, err: `field must contain at least one variable`, unimplemented: `unimplemented: function sin`},
 		{s: `SELECT nofunc(1.3) FROM cpu`, err: `undefined function nofunc()`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY * SLIMIT 5`, err: `unimplemented: SLIMIT with GROUP BY *`},
+		{s: `SELECT mean(value) FROM cpu GROUP BY * LIMIT 10 SLIMIT 5`, err: `unimplemented: SLIMIT with GROUP BY *`},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1mo)`, err: `invalid duration`},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1y)`, err: `invalid duration`},
+		{s: `SELECT host::tag FROM cpu`, err: `statement must have at least one field in select clause`},
+		{s: `SHOW SERIES EXACT CARDINALITY WHERE time > now() - 1h`, err: `unimplemented: SHOW SERIES CARDINALITY with a time condition`},
+		{s: `SHOW MEASUREMENTS WITH MEASUREMENT !~ /cpu/`, err: `found !~, expected =, =~ at line 1, char 36`},
+		{s: `SHOW MEASUREMENTS WHERE time > now() - 1h`, err: `unimplemented: SHOW MEASUREMENTS with a time condition`},
 	} {
 		t.Run(tt.s, func(t *testing.T) {
 			defer func() {
@@ -390,11 +422,103 @@ func TestTranspiler_Compile(t *testing.T) {
 					DefaultDatabase: "db0",
 				},
 			)
+			want := tt.err
+			if tt.unimplemented != "" {
+				want = tt.unimplemented
+			}
+			if _, err := transpiler.Transpile(context.Background(), tt.s); err != nil {
+				if got := err.Error(); got != want {
+					t.Errorf("unexpected error: got=%q want=%q", got, want)
+				}
+			} else if want != "" {
+				t.Errorf("expected error: %s", want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_ExpressionArgument(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+		},
+	)
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `SELECT mean(cpu_total - cpu_idle) FROM cpu`, err: `expected field argument in mean()`},
+		{s: `SELECT mean(a + b) FROM cpu`, err: `expected field argument in mean()`},
+		{s: `SELECT sum(a * b) FROM cpu`, err: `expected field argument in sum()`},
+		{s: `SELECT max(a - b) FROM cpu`, err: `expected field argument in max()`},
+		{s: `SELECT min(a / b) FROM cpu`, err: `expected field argument in min()`},
+		{s: `SELECT percentile(a + b, 5) FROM cpu`, err: `expected field argument in percentile()`},
+		{s: `SELECT mean(a + b) + 1 FROM cpu`, err: `expected field argument in mean()`},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			// The error must be reported exactly. A panic is reported as an internal error.
+			if _, err := transpiler.Transpile(context.Background(), tt.s); err == nil {
+				t.Fatal("expected error")
+			} else if got, want := err.Error(), tt.err; got != want {
+				t.Errorf("unexpected error: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_MixedAggregates(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+		},
+	)
+	for _, s := range []string{
+		`SELECT count(value), value FROM foo`,
+		`SELECT value, count(value) FROM foo`,
+		`SELECT count(value), * FROM cpu`,
+		`SELECT count(value), /ho/ FROM cpu`,
+		`SELECT max(*), host FROM cpu`,
+		`SELECT max(/val/), * FROM cpu`,
+	} {
+		s := s
+		t.Run(s, func(t *testing.T) {
+			// The transpiler is shared so this also checks for races when run with -race.
+			t.Parallel()
+			if _, err := transpiler.Transpile(context.Background(), s); err == nil {
+				t.Fatal("expected error")
+			} else if got, want := err.Error(), "mixing aggregate and non-aggregate queries is not supported"; got != want {
+				t.Errorf("unexpected error: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_MaxOperationsN(t *testing.T) {
+	// Each statement is transpiled into from, range, filter, group, keep, rename, and yield.
+	for _, tt := range []struct {
+		name string
+		s    string
+		n    int
+		err  string
+	}{
+		{name: "unlimited", s: `SELECT value FROM cpu; SELECT value FROM cpu`, n: 0},
+		{name: "at limit", s: `SELECT value FROM cpu`, n: 7},
+		{name: "exceeds limit", s: `SELECT value FROM cpu`, n: 6, err: `query too complex: exceeded maximum operation count`},
+		{name: "multiple statements at limit", s: `SELECT value FROM cpu; SELECT value FROM cpu`, n: 14},
+		{name: "multiple statements exceeds limit", s: `SELECT value FROM cpu; SELECT value FROM cpu`, n: 13, err: `query too complex: exceeded maximum operation count`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+					MaxOperationsN:  tt.n,
+				},
+			)
 			if _, err := transpiler.Transpile(context.Background(), tt.s); err != nil {
 				if got, want := err.Error(), tt.err; got != want {
-					if cause := errors.Cause(err); strings.HasPrefix(cause.Error(), "unimplemented") {
-						t.Skip(got)
-					}
 					t.Errorf("unexpected error: got=%q want=%q", got, want)
 				}
 			} else if tt.err != "" {
@@ -403,3 +527,1022 @@ func TestTranspiler_Compile(t *testing.T) {
 		})
 	}
 }
+
+func TestTranspiler_UnimplementedFunction(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `SELECT top(value, 1) FROM cpu`, err: `unimplemented: function top`},
+		{s: `SELECT bottom(value, 1) FROM cpu`, err: `unimplemented: function bottom`},
+		{s: `SELECT mode(value) FROM cpu`, err: `unimplemented: function mode`},
+		{s: `SELECT nofunc(value) FROM cpu`, err: `undefined function nofunc()`},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+				},
+			)
+			if _, err := transpiler.Transpile(context.Background(), tt.s); err == nil {
+				t.Errorf("expected error: %s", tt.err)
+			} else if got, want := err.Error(), tt.err; got != want {
+				t.Errorf("unexpected error: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_NowFn(t *testing.T) {
+	// Each call returns a later time so multiple calls would be noticed.
+	var n int
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			NowFn: func() time.Time {
+				n++
+				return spectests.Now().Add(time.Duration(n-1) * time.Second)
+			},
+		},
+	)
+
+	const q = `SELECT value FROM db0..cpu WHERE time >= now() - 1h AND time < now(); SELECT max(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m)`
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := n, 1; got != want {
+		t.Errorf("unexpected number of calls to NowFn: got=%d want=%d", got, want)
+	}
+
+	// Both statements read the same range ending at the same time.
+	const want = `range(start: 2010-09-15T08:00:00Z, stop: 2010-09-15T09:00:00Z)`
+	if got := ast.Format(pkg); strings.Count(got, want) != 2 {
+		t.Errorf("expected %s twice in transpiled query:\n%s", want, got)
+	}
+}
+
+func TestTranspiler_TimeConditionOrder(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+		},
+	)
+
+	// The time bounds are found wherever they appear in the AND chain.
+	const (
+		start = `time >= '2000-01-01T00:00:00Z'`
+		stop  = `time <= '2000-01-02T00:00:00Z'`
+		host  = `host = 'a'`
+	)
+	for _, cond := range [][3]string{
+		{start, stop, host},
+		{start, host, stop},
+		{stop, start, host},
+		{stop, host, start},
+		{host, start, stop},
+		{host, stop, start},
+	} {
+		q := `SELECT value FROM db0..cpu WHERE ` + strings.Join(cond[:], " AND ")
+		pkg, err := transpiler.Transpile(context.Background(), q)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", q, err)
+			continue
+		}
+
+		got := ast.Format(pkg)
+		for _, want := range []string{
+			`range(start: 2000-01-01T00:00:00Z, stop: 2000-01-02T00:00:00.000000001Z)`,
+			`r["host"] == "a"`,
+		} {
+			if !strings.Contains(got, want) {
+				t.Errorf("%s: expected %s in transpiled query:\n%s", q, want, got)
+			}
+		}
+	}
+}
+
+func TestTranspiler_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Cancel the context while the second statement is transpiled.
+	var n int
+	dbrpMappingSvc := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(ctx context.Context, filter platform.DBRPMappingFilterV2, opt ...platform.FindOptions) ([]*platform.DBRPMappingV2, int, error) {
+			if n++; n == 2 {
+				cancel()
+			}
+			return nil, 0, nil
+		},
+	}
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			FallbackToDBRP:  true,
+		},
+	)
+
+	q := strings.Repeat(`SELECT value FROM cpu;`, 10)
+	if _, err := transpiler.Transpile(ctx, q); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: got=%v want=%v", err, context.Canceled)
+	}
+	if got, want := n, 2; got != want {
+		t.Errorf("unexpected number of statements transpiled: got=%d want=%d", got, want)
+	}
+}
+
+func TestTranspileWithTimeout(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+		},
+	)
+
+	const q = `SELECT value FROM db0..cpu`
+	if _, err := influxql.TranspileWithTimeout(transpiler, q, time.Nanosecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error: got=%v want=%v", err, context.DeadlineExceeded)
+	}
+
+	got, err := influxql.TranspileWithTimeout(transpiler, q, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	want, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := ast.Format(want), ast.Format(got); want != got {
+		t.Errorf("unexpected ast\n%s", diff.LineDiff(want, got))
+	}
+}
+
+func TestTranspiler_DefaultDatabase(t *testing.T) {
+	// Use a mapping service that never finds a mapping so the
+	// bucket name falls back to the db/rp naming convention.
+	dbrpMappingSvc := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(ctx context.Context, filter platform.DBRPMappingFilterV2, opt ...platform.FindOptions) ([]*platform.DBRPMappingV2, int, error) {
+			return nil, 0, nil
+		},
+	}
+	const q = `SELECT value FROM cpu`
+
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			FallbackToDBRP: true,
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), q); err == nil {
+		t.Fatal("expected error")
+	} else if got, want := err.Error(), "unable to transpile: no database specified: use FROM db..measurement or set Config.DefaultDatabase"; got != want {
+		t.Errorf("unexpected error: got=%q want=%q", got, want)
+	}
+
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "mydb",
+			FallbackToDBRP:  true,
+		},
+	)
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := ast.Format(pkg), `from(bucket: "mydb/autogen")`; !strings.Contains(got, want) {
+		t.Errorf("expected %s in transpiled query:\n%s", want, got)
+	}
+}
+
+func TestTranspiler_DefaultRetentionPolicy(t *testing.T) {
+	// Use a mapping service that never finds a mapping so the
+	// bucket name falls back to the db/rp naming convention.
+	dbrpMappingSvc := &mock.DBRPMappingServiceV2{
+		FindManyFn: func(ctx context.Context, filter platform.DBRPMappingFilterV2, opt ...platform.FindOptions) ([]*platform.DBRPMappingV2, int, error) {
+			return nil, 0, nil
+		},
+	}
+	for _, tt := range []struct {
+		name string
+		s    string
+		rp   string
+		want string
+	}{
+		{name: "default", s: `SELECT value FROM db0..cpu`, want: `from(bucket: "db0/autogen")`},
+		{name: "configured", s: `SELECT value FROM db0..cpu`, rp: "oneweek", want: `from(bucket: "db0/oneweek")`},
+		{name: "explicit", s: `SELECT value FROM db0.alternate.cpu`, rp: "oneweek", want: `from(bucket: "db0/alternate")`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultRetentionPolicy: tt.rp,
+					FallbackToDBRP:         true,
+				},
+			)
+			pkg, err := transpiler.Transpile(context.Background(), tt.s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := ast.Format(pkg); !strings.Contains(got, tt.want) {
+				t.Errorf("expected %s in transpiled query:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestTranspiler_ImplicitTimeRange(t *testing.T) {
+	calls := make(map[string]int)
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			ImplicitTimeRange: func(db, rp, measurement string) (time.Time, time.Time, error) {
+				calls[db+"/"+rp+"/"+measurement]++
+				return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), nil
+			},
+		},
+	)
+
+	const q = `SELECT max(value), min(value) FROM db0..cpu; SELECT value FROM db0..mem; SELECT value FROM db0..mem WHERE time >= now() - 1h; SELECT mean(value) FROM db0..cpu GROUP BY time(10m)`
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if want := map[string]int{"db0/autogen/cpu": 1, "db0/autogen/mem": 1}; !cmp.Equal(want, calls) {
+		t.Errorf("unexpected calls -want/+got:\n%s", cmp.Diff(want, calls))
+	}
+
+	// The four sources without a time condition read the implicit time range,
+	// including the one with a GROUP BY interval that would otherwise stop at now.
+	// The stop is inclusive so the range stops a nanosecond after it.
+	const implicit = `range(start: 2020-01-01T00:00:00Z, stop: 2020-01-02T00:00:00.000000001Z)`
+	if got, want := strings.Count(ast.Format(pkg), implicit), 4; got != want {
+		t.Errorf("unexpected number of implicit time ranges: got=%d want=%d\n%s", got, want, ast.Format(pkg))
+	}
+
+	// Each of the federated buckets reads the implicit time range, and both
+	// functions are called with the same retention policy.
+	calls = make(map[string]int)
+	var rps []string
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			BucketFederationFn: func(db, rp string) []string {
+				rps = append(rps, rp)
+				return []string{"us-west", "us-east"}
+			},
+			ImplicitTimeRange: func(db, rp, measurement string) (time.Time, time.Time, error) {
+				calls[db+"/"+rp+"/"+measurement]++
+				return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), nil
+			},
+		},
+	)
+	pkg, err = transpiler.Transpile(context.Background(), `SELECT value FROM cpu`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := map[string]int{"db0/autogen/cpu": 1}; !cmp.Equal(want, calls) {
+		t.Errorf("unexpected calls -want/+got:\n%s", cmp.Diff(want, calls))
+	}
+	if want := []string{"autogen"}; !cmp.Equal(want, rps) {
+		t.Errorf("unexpected retention policies -want/+got:\n%s", cmp.Diff(want, rps))
+	}
+	if got, want := strings.Count(ast.Format(pkg), implicit), 2; got != want {
+		t.Errorf("unexpected number of implicit time ranges: got=%d want=%d\n%s", got, want, ast.Format(pkg))
+	}
+}
+
+func TestTranspiler_BucketFederation(t *testing.T) {
+	var rps []string
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			BucketFederationFn: func(db, rp string) []string {
+				rps = append(rps, rp)
+				switch db {
+				case "db0":
+					return []string{"us-west", "us-east"}
+				case "db1":
+					return []string{"eu-west"}
+				}
+				return nil
+			},
+		},
+	)
+
+	for _, tt := range []struct {
+		s    string
+		want []string
+	}{
+		{s: `SELECT value FROM db0..cpu`, want: []string{"us-west", "us-east"}},
+		{s: `SELECT value FROM cpu`, want: []string{"us-west", "us-east"}},
+		{s: `SELECT value FROM db1..cpu`, want: []string{"eu-west"}},
+		{s: `SELECT value FROM db2..cpu`, want: []string{""}},
+		{s: `SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(10m)`, want: []string{"us-west", "us-east"}},
+		{s: `SHOW MEASUREMENTS`, want: []string{"us-west", "us-east"}},
+		{s: `SHOW TAG VALUES WITH KEY = "host"`, want: []string{"us-west", "us-east"}},
+		{s: `SHOW SERIES ON db1`, want: []string{"eu-west"}},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			rps = nil
+			pkg, err := transpiler.Transpile(context.Background(), tt.s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// Every from must be bounded by a range that is piped directly into it.
+			var got []string
+			ast.Visit(pkg, func(node ast.Node) {
+				pipe, ok := node.(*ast.PipeExpression)
+				if !ok {
+					return
+				}
+				if call, ok := pipe.Argument.(*ast.CallExpression); ok && isCall(call, "from") {
+					if !isCall(pipe.Call, "range") {
+						t.Errorf("from is piped into %s instead of range:\n%s", ast.Format(pipe.Call.Callee), ast.Format(pkg))
+					}
+				}
+			})
+			ast.Visit(pkg, func(node ast.Node) {
+				if call, ok := node.(*ast.CallExpression); ok && isCall(call, "from") {
+					bucket := call.Arguments[0].(*ast.ObjectExpression).Properties[0]
+					if bucket.Key.Key() == "bucket" {
+						got = append(got, bucket.Value.(*ast.StringLiteral).Value)
+					} else {
+						got = append(got, "")
+					}
+				}
+			})
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected buckets -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+
+			// The default retention policy is used when it is not in the query.
+			for _, rp := range rps {
+				if rp != "autogen" {
+					t.Errorf("unexpected retention policy: got=%q want=%q", rp, "autogen")
+				}
+			}
+		})
+	}
+
+	// A database is still required when the buckets are federated.
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			BucketFederationFn: func(db, rp string) []string {
+				return []string{db}
+			},
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), `SELECT value FROM cpu`); err == nil {
+		t.Error("expected error")
+	} else if got, want := err.Error(), "unable to transpile: no database specified: use FROM db..measurement or set Config.DefaultDatabase"; got != want {
+		t.Errorf("unexpected error: got=%q want=%q", got, want)
+	}
+}
+
+// isCall returns true if the call expression calls the function with the name.
+func isCall(call *ast.CallExpression, name string) bool {
+	ident, ok := call.Callee.(*ast.Identifier)
+	return ok && ident.Name == name
+}
+
+func TestTranspiler_IdentifierFn(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			IdentifierFn: func(n int) string {
+				return fmt.Sprintf("query_a1b2c3_%d", n)
+			},
+		},
+	)
+
+	q := strings.Repeat(`SELECT mean(value), max(value) FROM db0..cpu;`, 100)
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, stmt := range pkg.Files[0].Body {
+		assign, ok := stmt.(*ast.VariableAssignment)
+		if !ok {
+			continue
+		}
+		if name := assign.ID.Name; seen[name] {
+			t.Errorf("duplicate identifier: %s", name)
+		} else if !strings.HasPrefix(name, "query_a1b2c3_") {
+			t.Errorf("unexpected identifier: %s", name)
+		}
+		seen[assign.ID.Name] = true
+	}
+	if got, want := len(seen), 200; got != want {
+		t.Errorf("unexpected number of identifiers: got=%d want=%d", got, want)
+	}
+
+	if src := ast.Format(pkg); ast.Check(parser.ParseSource(src)) > 0 {
+		t.Fatalf("unable to parse the flux script:\n%s", src)
+	}
+}
+
+func TestTranspiler_IdentifierFnCollision(t *testing.T) {
+	// Every name is the same so only the first variable can use it.
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+			IdentifierFn: func(int) string {
+				return "x"
+			},
+		},
+	)
+
+	pkg, err := transpiler.Transpile(context.Background(), `SELECT mean(value), max(value), min(value) FROM db0..cpu`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var got []string
+	for _, stmt := range pkg.Files[0].Body {
+		if assign, ok := stmt.(*ast.VariableAssignment); ok {
+			got = append(got, assign.ID.Name)
+		}
+	}
+	if want := []string{"x", "t0", "t1"}; !cmp.Equal(want, got) {
+		t.Errorf("unexpected identifiers -want/+got:\n%s", cmp.Diff(want, got))
+	}
+}
+
+func TestTranspiler_Middleware(t *testing.T) {
+	type call struct {
+		q   string
+		pkg *ast.Package
+		err error
+	}
+	var calls []call
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Middleware: func(next influxql.TranspileFunc) influxql.TranspileFunc {
+				return func(ctx context.Context, q string) (*ast.Package, error) {
+					pkg, err := next(ctx, q)
+					calls = append(calls, call{q: q, pkg: pkg, err: err})
+					return pkg, err
+				}
+			},
+		},
+	)
+
+	const q = `SELECT value FROM db0..cpu`
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("unexpected number of calls: got=%d want=1", len(calls))
+	}
+	if got := calls[0]; got.q != q || got.pkg != pkg || got.err != nil {
+		t.Errorf("unexpected call: %+v", got)
+	}
+
+	// Errors are passed through the middleware.
+	if _, err := transpiler.Transpile(context.Background(), `SELECT value FROM`); err == nil {
+		t.Fatal("expected error")
+	}
+	if len(calls) != 2 {
+		t.Fatalf("unexpected number of calls: got=%d want=2", len(calls))
+	}
+	if got := calls[1]; got.pkg != nil || got.err == nil {
+		t.Errorf("unexpected call: %+v", got)
+	}
+
+	// The middleware can replace the result.
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			Middleware: func(next influxql.TranspileFunc) influxql.TranspileFunc {
+				return func(ctx context.Context, q string) (*ast.Package, error) {
+					return nil, errors.New("rejected")
+				}
+			},
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), q); err == nil || err.Error() != "rejected" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestTranspiler_DatabaseNameRequired(t *testing.T) {
+	for _, s := range []string{
+		`SHOW RETENTION POLICIES`,
+		`SHOW TAG VALUES WITH KEY = "host"`,
+		`SHOW SERIES`,
+		`SHOW SERIES CARDINALITY`,
+		`SHOW MEASUREMENTS`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			transpiler := influxql.NewTranspiler(dbrpMappingSvc)
+			if _, err := transpiler.Transpile(context.Background(), s); err == nil {
+				t.Fatal("expected error")
+			} else if got, want := err.Error(), "database name required"; got != want {
+				t.Errorf("unexpected error: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_ShowDatabases(t *testing.T) {
+	transpiler := influxql.NewTranspiler(dbrpMappingSvc)
+	pkg, err := transpiler.Transpile(context.Background(), `SHOW DATABASES`)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	body := pkg.Files[0].Body
+	if len(body) != 1 {
+		t.Fatalf("unexpected number of statements: got=%d want=1", len(body))
+	}
+	expr := body[0].(*ast.ExpressionStatement).Expression
+
+	// The last call yields the results.
+	pipe, ok := expr.(*ast.PipeExpression)
+	if !ok {
+		t.Fatalf("expected pipe expression, got %T", expr)
+	} else if got, want := pipe.Call.Callee.(*ast.Identifier).Name, "yield"; got != want {
+		t.Errorf("unexpected final call: got=%q want=%q", got, want)
+	}
+
+	// The source of the pipeline is v1.databases() and it has no input.
+	for {
+		p, ok := expr.(*ast.PipeExpression)
+		if !ok {
+			break
+		}
+		expr = p.Argument
+	}
+	call, ok := expr.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("expected call expression, got %T", expr)
+	} else if got, want := ast.Format(call), "v1.databases()"; got != want {
+		t.Errorf("unexpected source: got=%q want=%q", got, want)
+	}
+}
+
+func TestTranspiler_StrictMode(t *testing.T) {
+	for _, tt := range []struct {
+		s   string
+		err string
+	}{
+		{s: `SELECT value FROM cpu OFFSET 10`, err: `unimplemented: OFFSET without LIMIT`},
+		{s: `SELECT value FROM cpu SOFFSET 10`, err: `unimplemented: SOFFSET without SLIMIT`},
+		{s: `SELECT value FROM cpu tz('America/Los_Angeles')`, err: `unimplemented: tz() function`},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) fill(linear)`, err: `unimplemented: fill(linear)`},
+		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) fill(previous)`, err: `unimplemented: fill(previous) with a selector`},
+		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) fill(0)`, err: `unimplemented: fill(0) with a selector`},
+		{s: `SHOW TAG VALUES WITH KEY = "host" WHERE region = 'us-west'`, err: `unimplemented: SHOW TAG VALUES with WHERE clause`},
+		{s: `SHOW SERIES LIMIT 10`, err: `unimplemented: SHOW SERIES with LIMIT or OFFSET`},
+		{s: `SHOW MEASUREMENTS LIMIT 10`, err: `unimplemented: SHOW MEASUREMENTS with LIMIT or OFFSET`},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			for _, strict := range []bool{false, true} {
+				transpiler := influxql.NewTranspilerWithConfig(
+					dbrpMappingSvc,
+					influxql.Config{
+						DefaultDatabase: "db0",
+						StrictMode:      strict,
+					},
+				)
+				_, err := transpiler.Transpile(context.Background(), tt.s)
+				if !strict {
+					if err != nil {
+						t.Errorf("unexpected error in lenient mode: %s", err)
+					}
+					continue
+				}
+				if err == nil {
+					t.Errorf("expected error in strict mode: %s", tt.err)
+				} else if got, want := err.Error(), tt.err; got != want {
+					t.Errorf("unexpected error in strict mode: got=%q want=%q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestTranspiler_Warnings(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		want []string
+	}{
+		{s: `SELECT value FROM cpu`},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(10m, now())`, want: []string{`GROUP BY time() with a now() offset is deprecated`}},
+		{s: `SELECT mean(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) fill(linear)`, want: []string{`fill(linear) is not implemented and was ignored`}},
+		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m)`},
+		{s: `SELECT max(value) FROM cpu WHERE time >= now() - 1h GROUP BY time(1m) fill(previous)`, want: []string{`fill(previous) with a selector is not implemented and was ignored`}},
+		{s: `SELECT value FROM cpu LIMIT 10`},
+		{s: `SELECT value FROM cpu OFFSET 10 tz('America/Los_Angeles')`, want: []string{
+			`OFFSET without LIMIT is not implemented and was ignored`,
+			`tz() function is not implemented and was ignored`,
+		}},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			var got []string
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+					OnWarning: func(w influxql.Warning) {
+						got = append(got, w.Message)
+					},
+				},
+			)
+			if _, err := transpiler.Transpile(context.Background(), tt.s); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !cmp.Equal(tt.want, got) {
+				t.Errorf("unexpected warnings -want/+got:\n%s", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestTranspiler_TranspileStatement(t *testing.T) {
+	for _, s := range []string{
+		`SELECT value FROM db0..cpu`,
+		`SELECT mean(value) FROM db0..cpu WHERE host = 'server01' AND time >= now() - 10m GROUP BY time(1m)`,
+		`SELECT max(value) FROM db0..cpu, db0..mem GROUP BY host`,
+		`SHOW DATABASES`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+					Now:             spectests.Now(),
+				},
+			)
+			want, err := transpiler.Transpile(context.Background(), s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			stmt, err := influxqllib.ParseStatement(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := influxql.TranspileStatement(context.Background(), transpiler, stmt)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if want, got := ast.Format(want), ast.Format(got); want != got {
+				t.Errorf("unexpected ast\n%s", diff.LineDiff(want, got))
+			}
+		})
+	}
+}
+
+func TestTranspiler_TranspileStatementDefaultDatabase(t *testing.T) {
+	for _, s := range []string{
+		`SHOW TAG VALUES WITH KEY = "host"`,
+		`SHOW MEASUREMENTS`,
+		`SHOW RETENTION POLICIES`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+				},
+			)
+			stmt, err := influxqllib.ParseStatement(s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			want := stmt.String()
+			if _, err := influxql.TranspileStatement(context.Background(), transpiler, stmt); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			// The default database must not be written into the statement.
+			if got := stmt.String(); got != want {
+				t.Errorf("unexpected statement: got=%q want=%q", got, want)
+			}
+		})
+	}
+}
+
+func TestTranspiler_TranspileReader(t *testing.T) {
+	for _, s := range []string{
+		`SELECT value FROM db0..cpu`,
+		`SELECT mean(value) FROM db0..cpu WHERE host = 'server01' AND time >= now() - 10m GROUP BY time(1m)`,
+		`SELECT value FROM db0..cpu; SELECT max(value) FROM db0..mem GROUP BY host`,
+		`SHOW DATABASES`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+					Now:             spectests.Now(),
+				},
+			)
+			want, err := transpiler.Transpile(context.Background(), s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			got, err := influxql.TranspileReader(context.Background(), transpiler, strings.NewReader(s))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if want, got := ast.Format(want), ast.Format(got); want != got {
+				t.Errorf("unexpected ast\n%s", diff.LineDiff(want, got))
+			}
+		})
+	}
+}
+
+func TestTranspiler_TranspileToFlux(t *testing.T) {
+	for _, s := range []string{
+		`SELECT value FROM db0..cpu`,
+		`SELECT mean(value) FROM db0..cpu WHERE host = 'server01' AND time >= now() - 10m GROUP BY time(1m)`,
+		`SELECT value FROM db0..cpu; SELECT max(value) FROM db0..mem GROUP BY host`,
+		`SHOW DATABASES`,
+	} {
+		t.Run(s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase: "db0",
+					Now:             spectests.Now(),
+				},
+			)
+			got, err := influxql.TranspileToFlux(context.Background(), transpiler, s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ast.Check(parser.ParseSource(got)) > 0 {
+				t.Fatalf("unable to parse the flux script:\n%s", got)
+			}
+
+			pkg, err := transpiler.Transpile(context.Background(), s)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if want := ast.Format(pkg); got != want {
+				t.Errorf("unexpected flux script -want/+got:\n%s", diff.LineDiff(want, got))
+			}
+		})
+	}
+}
+
+func TestTranspiler_TranspileVerbose(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+		},
+	)
+
+	const q = `SELECT value FROM db0..cpu; SELECT max(value) FROM db0..mem GROUP BY host; SELECT value FROM db0..cpu tz('America/Los_Angeles')`
+	res, err := influxql.TranspileVerbose(context.Background(), transpiler, q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := len(res.Statements), 3; got != want {
+		t.Errorf("unexpected number of statements: got=%d want=%d", got, want)
+	}
+	if want := []influxql.Warning{{Message: `tz() function is not implemented and was ignored`}}; !cmp.Equal(want, res.Warnings) {
+		t.Errorf("unexpected warnings -want/+got:\n%s", cmp.Diff(want, res.Warnings))
+	}
+
+	pkg, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want, got := ast.Format(pkg), ast.Format(res.Package); want != got {
+		t.Errorf("unexpected ast\n%s", diff.LineDiff(want, got))
+	}
+}
+
+func TestTranspiler_FunctionRegistry(t *testing.T) {
+	registry := map[string]influxql.FunctionFunc{
+		"myfunc": func(call *influxqllib.Call) (*ast.CallExpression, error) {
+			if len(call.Args) != 2 {
+				return nil, errors.New("myfunc requires two arguments")
+			}
+			n, ok := call.Args[1].(*influxqllib.IntegerLiteral)
+			if !ok {
+				return nil, errors.New("expected integer argument")
+			}
+			return &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "myfunc"},
+				Arguments: []ast.Expression{
+					&ast.ObjectExpression{
+						Properties: []*ast.Property{{
+							Key:   &ast.Identifier{Name: "n"},
+							Value: &ast.IntegerLiteral{Value: n.Val},
+						}},
+					},
+				},
+			}, nil
+		},
+		// Registered functions are used instead of the built-in functions.
+		"mean": func(call *influxqllib.Call) (*ast.CallExpression, error) {
+			return &ast.CallExpression{
+				Callee: &ast.Identifier{Name: "fastMean"},
+			}, nil
+		},
+	}
+
+	for _, tt := range []struct {
+		s    string
+		want string
+		err  string
+	}{
+		{s: `SELECT myfunc(value, 5) FROM db0..cpu`, want: `|> myfunc(n: 5)`},
+		{s: `SELECT mean(value) FROM db0..cpu`, want: `|> fastMean()`},
+		{s: `SELECT myfunc(value) FROM db0..cpu`, err: `unable to transpile function myfunc: myfunc requires two arguments`},
+		{s: `SELECT myfunc('value', 5) FROM db0..cpu`, err: `expected field argument in myfunc()`},
+	} {
+		t.Run(tt.s, func(t *testing.T) {
+			transpiler := influxql.NewTranspilerWithConfig(
+				dbrpMappingSvc,
+				influxql.Config{
+					DefaultDatabase:  "db0",
+					FunctionRegistry: registry,
+				},
+			)
+			pkg, err := transpiler.Transpile(context.Background(), tt.s)
+			if tt.err != "" {
+				if err == nil {
+					t.Fatalf("expected error: %s", tt.err)
+				} else if got, want := err.Error(), tt.err; got != want {
+					t.Fatalf("unexpected error: got=%q want=%q", got, want)
+				}
+				return
+			} else if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			if got := ast.Format(pkg); !strings.Contains(got, tt.want) {
+				t.Errorf("expected %q in transpiled query:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+type countingFieldKeys struct {
+	keys  map[string][]string
+	calls int
+}
+
+func (c *countingFieldKeys) FieldKeysForMeasurement(ctx context.Context, db, rp, measurement string) ([]string, error) {
+	c.calls++
+	return c.keys[measurement], nil
+}
+
+func TestTranspiler_SchemaResolver(t *testing.T) {
+	resolver := &countingFieldKeys{
+		keys: map[string][]string{"cpu": {"usage_user", "value"}},
+	}
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			SchemaResolver:  resolver,
+		},
+	)
+
+	// The field keys of a measurement are only resolved once.
+	if _, err := transpiler.Transpile(context.Background(), `SELECT mean(*), max(*) FROM db0..cpu`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := resolver.calls, 1; got != want {
+		t.Errorf("unexpected number of calls to the resolver: got=%d want=%d", got, want)
+	}
+
+	// Without a resolver, the wildcard cannot be expanded.
+	transpiler = influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+		},
+	)
+	if _, err := transpiler.Transpile(context.Background(), `SELECT * FROM db0..cpu`); err == nil {
+		t.Fatal("expected error")
+	} else if got, want := err.Error(), "unimplemented: field wildcard"; got != want {
+		t.Errorf("unexpected error: got=%q want=%q", got, want)
+	}
+	for _, name := range []string{"max", "min", "first", "last", "mean"} {
+		if _, err := transpiler.Transpile(context.Background(), `SELECT `+name+`(*) FROM db0..cpu`); err == nil {
+			t.Errorf("%s(*): expected error", name)
+		} else if got, want := err.Error(), "unimplemented: wildcard function"; got != want {
+			t.Errorf("%s(*): unexpected error: got=%q want=%q", name, got, want)
+		}
+	}
+
+	// Without a resolver, count(*) counts the points of every field.
+	if _, err := transpiler.Transpile(context.Background(), `SELECT count(*) FROM db0..cpu`); err != nil {
+		t.Errorf("count(*): unexpected error: %s", err)
+	}
+}
+
+func TestTranspiler_Explain(t *testing.T) {
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+		},
+	)
+
+	const q = `SELECT mean(value) FROM db0..cpu WHERE host = 'server01' GROUP BY time(1m)`
+	pkg, err := transpiler.Transpile(context.Background(), "EXPLAIN "+q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Find the plan within the returned rows.
+	var plan string
+	ast.Walk(ast.CreateVisitor(func(node ast.Node) {
+		if p, ok := node.(*ast.Property); ok {
+			if key, ok := p.Key.(*ast.StringLiteral); ok && key.Value == "QUERY PLAN" {
+				plan = p.Value.(*ast.StringLiteral).Value
+			}
+		}
+	}), pkg)
+
+	if ast.Check(parser.ParseSource(plan)) > 0 {
+		t.Fatalf("unable to parse the query plan:\n%s", plan)
+	}
+
+	want, err := transpiler.Transpile(context.Background(), q)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got, want := plan, ast.Format(want); got != want {
+		t.Errorf("unexpected query plan -want/+got:\n%s", diff.LineDiff(want, got))
+	}
+
+	if _, err := transpiler.Transpile(context.Background(), "EXPLAIN ANALYZE "+q); err == nil {
+		t.Fatal("expected error")
+	} else if got, want := err.Error(), "unimplemented: EXPLAIN ANALYZE"; got != want {
+		t.Errorf("unexpected error: got=%q want=%q", got, want)
+	}
+}
+
+func benchmarkTranspiler(b *testing.B, n int) {
+	statements := []string{
+		`SELECT value FROM db0..cpu WHERE host = 'server01'`,
+		`SELECT mean(value) FROM db0..cpu WHERE time >= now() - 1h GROUP BY time(1m), host`,
+		`SELECT max(usage_user), min(usage_system) FROM db0..cpu WHERE region = 'us-west' GROUP BY host`,
+		`SELECT usage_user + usage_system FROM db0..cpu`,
+	}
+	stmts := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		stmts = append(stmts, statements[i%len(statements)])
+	}
+	q := strings.Join(stmts, "; ")
+
+	transpiler := influxql.NewTranspilerWithConfig(
+		dbrpMappingSvc,
+		influxql.Config{
+			DefaultDatabase: "db0",
+			Now:             spectests.Now(),
+		},
+	)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := transpiler.Transpile(context.Background(), q); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTranspiler_SingleStatement(b *testing.B) { benchmarkTranspiler(b, 1) }
+func BenchmarkTranspiler_10Statements(b *testing.B)    { benchmarkTranspiler(b, 10) }
+func BenchmarkTranspiler_100Statements(b *testing.B)   { benchmarkTranspiler(b, 100) }